@@ -0,0 +1,15 @@
+// Command go-syntax-vet runs the go-syntax rules as go/analysis
+// analyzers, standalone or with go vet:
+//
+//	go vet -vettool=$(which go-syntax-vet) ./...
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/multichecker"
+
+	"github.com/thierry-f-78/go-syntax/pkg/analyzer"
+)
+
+func main() {
+	multichecker.Main(analyzer.Analyzers()...)
+}
@@ -1,3 +1,17 @@
 module github.com/thierry-f-78/go-syntax
 
-go 1.21
+go 1.22.0
+
+require (
+	github.com/golangci/plugin-module-register v0.1.1
+	github.com/santhosh-tekuri/jsonschema/v5 v5.3.1
+	golang.org/x/mod v0.23.0
+	golang.org/x/term v0.29.0
+	golang.org/x/tools v0.30.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	golang.org/x/sync v0.11.0 // indirect
+	golang.org/x/sys v0.30.0 // indirect
+)
@@ -0,0 +1,371 @@
+// Package analyzer exposes the registered rules as go/analysis analyzers,
+// for go vet -vettool, gopls and the other analysis drivers.
+//
+// The analyzers use no facts. The typed rules use the type information of
+// the pass, the package rules check its files at once. The diagnostics
+// honour the //nolint comments and carry the suggested fix of the issue,
+// if any.
+package analyzer
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+
+	"github.com/thierry-f-78/go-syntax/pkg/fix"
+	"github.com/thierry-f-78/go-syntax/pkg/linter"
+	"github.com/thierry-f-78/go-syntax/pkg/rules"
+	syntax "github.com/thierry-f-78/go-syntax/pkg/types"
+)
+
+// Analyzers returns an analyzer for each registered rule.
+func Analyzers() []*analysis.Analyzer {
+	var analyzers []*analysis.Analyzer
+	var info rules.Info
+	for _, info = range rules.All() {
+		analyzers = append(analyzers, New(info))
+	}
+	return analyzers
+}
+
+// Name returns the name of the analyzer of rule. Analyzer names are Go
+// identifiers: the dashes of the rule name become underscores.
+func Name(rule string) string {
+	return strings.ReplaceAll(rule, "-", "_")
+}
+
+// New returns the analyzer of the rule info. The options of the rule are
+// flags of the analyzer.
+func New(info rules.Info) *analysis.Analyzer {
+	var a *analysis.Analyzer
+	a = &analysis.Analyzer{
+		Name: Name(info.Name),
+		Doc:  info.Description,
+	}
+
+	var options map[string]func() interface{}
+	options = optionFlags(&a.Flags, info)
+	a.Run = func(pass *analysis.Pass) (interface{}, error) {
+		var rule syntax.Rule
+		var err error
+		rule, err = newRule(info, options)
+		if err != nil {
+			return nil, err
+		}
+		return nil, run(pass, rule)
+	}
+	return a
+}
+
+// NewWithOptions returns the analyzer of the rule info, its options set
+// once and for all to options instead of flags. Invalid options are
+// reported now.
+func NewWithOptions(info rules.Info, options map[string]interface{}) (*analysis.Analyzer, error) {
+	var values map[string]func() interface{}
+	values = make(map[string]func() interface{})
+	var name string
+	var value interface{}
+	for name, value = range options {
+		values[name] = constant(value)
+	}
+
+	var err error
+	_, err = newRule(info, values)
+	if err != nil {
+		return nil, err
+	}
+
+	return &analysis.Analyzer{
+		Name: Name(info.Name),
+		Doc:  info.Description,
+		Run: func(pass *analysis.Pass) (interface{}, error) {
+			var rule syntax.Rule
+			var err error
+			rule, err = newRule(info, values)
+			if err != nil {
+				return nil, err
+			}
+			return nil, run(pass, rule)
+		},
+	}, nil
+}
+
+func constant(value interface{}) func() interface{} {
+	return func() interface{} { return value }
+}
+
+// optionFlags defines a flag for each option of info, and returns the
+// functions giving their value.
+func optionFlags(flags *flag.FlagSet, info rules.Info) map[string]func() interface{} {
+	var options map[string]func() interface{}
+	options = make(map[string]func() interface{})
+
+	var option rules.OptionInfo
+	for _, option = range info.Options {
+		switch option.Type {
+		case "bool":
+			var b *bool
+			var def bool
+			def, _ = option.Default.(bool)
+			b = flags.Bool(option.Name, def, option.Description)
+			options[option.Name] = func() interface{} { return *b }
+		case "int":
+			var n *int
+			var def int
+			def, _ = option.Default.(int)
+			n = flags.Int(option.Name, def, option.Description)
+			options[option.Name] = func() interface{} { return *n }
+		case "string":
+			var s *string
+			var def string
+			def, _ = option.Default.(string)
+			s = flags.String(option.Name, def, option.Description)
+			options[option.Name] = func() interface{} { return *s }
+		}
+	}
+	return options
+}
+
+// newRule returns an instance of the rule info, its options set to the
+// values of options. Each pass gets its own: a rule may keep state.
+func newRule(info rules.Info, options map[string]func() interface{}) (syntax.Rule, error) {
+	var rule syntax.Rule
+	rule = info.New()
+
+	var names []string
+	var name string
+	for name = range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name = range names {
+		var configurable syntax.ConfigurableRule
+		var ok bool
+		configurable, ok = rule.(syntax.ConfigurableRule)
+		if !ok {
+			return nil, fmt.Errorf("rule %q has no options", info.Name)
+		}
+		var err error
+		err = configurable.SetOption(name, options[name]())
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %v", info.Name, err)
+		}
+	}
+	return rule, nil
+}
+
+// run checks the files of pass with rule.
+func run(pass *analysis.Pass, rule syntax.Rule) error {
+	var fixes map[fixKey]*analysis.SuggestedFix
+	fixes = suggestedFixes(pass, rule.Name())
+	// A fix shared by several issues, such as the named results of a
+	// function, comes with the first one only: drivers applying every
+	// fix would otherwise see overlapping edits.
+	var attached map[*analysis.SuggestedFix]bool
+	attached = make(map[*analysis.SuggestedFix]bool)
+
+	// A package rule checks the files of the pass at once.
+	var packageIssues map[string][]syntax.Issue
+	var packageRule syntax.PackageRule
+	var isPackage bool
+	packageRule, isPackage = rule.(syntax.PackageRule)
+	if isPackage {
+		packageIssues = make(map[string][]syntax.Issue)
+		var issue syntax.Issue
+		for _, issue = range packageRule.CheckPackage(pass.Fset, pass.Files) {
+			packageIssues[issue.File] = append(packageIssues[issue.File], issue)
+		}
+	}
+
+	var file *ast.File
+	for _, file = range pass.Files {
+		var tokFile *token.File
+		tokFile = pass.Fset.File(file.Pos())
+
+		var issues []syntax.Issue
+		var typed syntax.TypedRule
+		var ok bool
+		typed, ok = rule.(syntax.TypedRule)
+		switch {
+		case isPackage:
+			issues = packageIssues[tokFile.Name()]
+		case ok && pass.TypesInfo != nil:
+			issues = typed.CheckTyped(pass.Fset, file, pass.Pkg, pass.TypesInfo)
+		default:
+			issues = rule.Check(pass.Fset, file)
+		}
+		issues = linter.FilterNolint(issues, file, pass.Fset)
+		var issue syntax.Issue
+		for _, issue = range issues {
+			var pos token.Pos
+			pos = tokFile.LineStart(issue.Line) + token.Pos(issue.Column-1)
+
+			var diagnostic analysis.Diagnostic
+			diagnostic = analysis.Diagnostic{
+				Pos:      pos,
+				Category: issue.Rule,
+				Message:  issue.Message,
+			}
+			var suggested *analysis.SuggestedFix
+			suggested = fixes[fixKey{file: tokFile.Name(), offset: tokFile.Offset(pos)}]
+			if suggested != nil && !attached[suggested] {
+				attached[suggested] = true
+				diagnostic.SuggestedFixes = []analysis.SuggestedFix{*suggested}
+			}
+			pass.Report(diagnostic)
+		}
+	}
+	return nil
+}
+
+// fixKey locates an issue.
+type fixKey struct {
+	file   string
+	offset int
+}
+
+// suggestedFixes returns the fixes of the issues of rule in the package of
+// pass. The fixers work on a copy of the files parsed anew, so that the
+// analyzers sharing the files never see them changed; files that no
+// longer match their content on disk, such as unsaved editor buffers, get
+// no fixes.
+func suggestedFixes(pass *analysis.Pass, rule string) map[fixKey]*analysis.SuggestedFix {
+	var fixer fix.Fixer
+	fixer = fix.Fixers[rule]
+	if rule == "naked-return" {
+		fixer = fix.Fixers["named-returns"]
+	}
+	if fixer == nil || len(pass.Files) == 0 {
+		return nil
+	}
+
+	var fset *token.FileSet
+	fset = token.NewFileSet()
+	var files []*ast.File
+	var passFiles map[string]*token.File
+	passFiles = make(map[string]*token.File)
+	var sources map[string][]byte
+	sources = make(map[string][]byte)
+	var file *ast.File
+	for _, file = range pass.Files {
+		var tokFile *token.File
+		tokFile = pass.Fset.File(file.Pos())
+		passFiles[tokFile.Name()] = tokFile
+
+		var src []byte
+		var err error
+		src, err = os.ReadFile(tokFile.Name())
+		if err != nil || len(src) != tokFile.Size() {
+			return nil
+		}
+		sources[tokFile.Name()] = src
+		var copied *ast.File
+		copied, err = parser.ParseFile(fset, tokFile.Name(), src, parser.ParseComments)
+		if err != nil {
+			return nil
+		}
+		files = append(files, copied)
+	}
+
+	var p *fix.Package
+	var err error
+	p, err = fix.NewPackage(fset, files, importerOf(pass.Pkg))
+	if err != nil {
+		return nil
+	}
+
+	var fixes map[fixKey]*analysis.SuggestedFix
+	fixes = make(map[fixKey]*analysis.SuggestedFix)
+	for _, file = range p.Files {
+		var tokFile *token.File
+		tokFile = fset.File(file.Pos())
+		var passFile *token.File
+		passFile = passFiles[tokFile.Name()]
+
+		var suggestion fix.Suggestion
+		for _, suggestion = range fixer(p, file) {
+			if suggestion.Rule != rule {
+				continue
+			}
+
+			var edit syntax.TextEdit
+			edit, err = formattedEdit(sources[tokFile.Name()], suggestion.Fix.Edits)
+			if err != nil {
+				continue
+			}
+			var suggested *analysis.SuggestedFix
+			suggested = &analysis.SuggestedFix{
+				Message: suggestion.Fix.Description,
+				TextEdits: []analysis.TextEdit{{
+					Pos:     passFile.Pos(edit.Start),
+					End:     passFile.Pos(edit.End),
+					NewText: []byte(edit.NewText),
+				}},
+			}
+
+			var pos token.Pos
+			for _, pos = range suggestion.Positions {
+				fixes[fixKey{file: tokFile.Name(), offset: tokFile.Offset(pos)}] = suggested
+			}
+		}
+	}
+	return fixes
+}
+
+// formattedEdit returns the edit turning src into src with edits applied
+// and formatted. The fixes count on the formatting, which the drivers do
+// not do.
+func formattedEdit(src []byte, edits []syntax.TextEdit) (syntax.TextEdit, error) {
+	var after []byte
+	var err error
+	after, err = fix.Apply(src, edits)
+	if err != nil {
+		return syntax.TextEdit{}, err
+	}
+
+	var prefix int
+	for prefix < len(src) && prefix < len(after) && src[prefix] == after[prefix] {
+		prefix++
+	}
+	var suffix int
+	for suffix < len(src)-prefix && suffix < len(after)-prefix && src[len(src)-1-suffix] == after[len(after)-1-suffix] {
+		suffix++
+	}
+	return syntax.TextEdit{
+		Start:   prefix,
+		End:     len(src) - suffix,
+		NewText: string(after[prefix : len(after)-suffix]),
+	}, nil
+}
+
+// importerOf returns an importer of the packages imported by pkg.
+func importerOf(pkg *types.Package) types.Importer {
+	var imports map[string]*types.Package
+	imports = map[string]*types.Package{"unsafe": types.Unsafe}
+	var imported *types.Package
+	for _, imported = range pkg.Imports() {
+		imports[imported.Path()] = imported
+	}
+	return importerFunc(func(path string) (*types.Package, error) {
+		var found *types.Package
+		found = imports[path]
+		if found == nil {
+			return nil, fmt.Errorf("package %q is not imported by %s", path, pkg.Path())
+		}
+		return found, nil
+	})
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) {
+	return f(path)
+}
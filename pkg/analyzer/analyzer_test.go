@@ -0,0 +1,44 @@
+package analyzer
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"github.com/thierry-f-78/go-syntax/pkg/rules"
+)
+
+// The test data has a package per analyzer, named after it, with the
+// fixed files as .golden files.
+func TestAnalyzers(t *testing.T) {
+	var a *analysis.Analyzer
+	for _, a = range Analyzers() {
+		t.Run(a.Name, func(t *testing.T) {
+			var err error
+			err = analysis.Validate([]*analysis.Analyzer{a})
+			if err != nil {
+				t.Fatalf("Invalid analyzer: %v", err)
+			}
+			analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), a, a.Name)
+		})
+	}
+}
+
+func TestOptionFlags(t *testing.T) {
+	var info rules.Info
+	var ok bool
+	info, ok = rules.Lookup("naked-return")
+	if !ok {
+		t.Fatalf("Rule naked-return is not registered")
+	}
+
+	var a *analysis.Analyzer
+	a = New(info)
+	var err error
+	err = a.Flags.Set("max-func-lines", "3")
+	if err != nil {
+		t.Fatalf("Failed to set max-func-lines: %v", err)
+	}
+	analysistest.Run(t, analysistest.TestData(), a, "options")
+}
@@ -0,0 +1,5 @@
+package a
+
+const size = 1024 // want "Constant declaration without explicit type is not allowed"
+
+const name = "go-syntax"
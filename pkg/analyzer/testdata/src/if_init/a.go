@@ -0,0 +1,8 @@
+package a
+
+func check(f func() error) error {
+	if err := f(); err != nil { // want "If statement with initialization is not allowed."
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,8 @@
+package a
+
+import "strconv"
+
+func parse(s string) (n int, err error) {
+	n, err = strconv.Atoi(s)
+	return // want "Naked return is not allowed"
+}
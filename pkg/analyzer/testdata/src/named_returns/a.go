@@ -0,0 +1,8 @@
+package a
+
+import "strconv"
+
+func parse(s string) (n int, err error) { // want "Named return parameters are not allowed" "Named return parameters are not allowed"
+	n, err = strconv.Atoi(s)
+	return n, err
+}
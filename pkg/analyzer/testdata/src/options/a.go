@@ -0,0 +1,11 @@
+package a
+
+func short() (err error) {
+	return
+}
+
+func long() (err error) {
+	err = nil
+	err = nil
+	return // want "Naked return is not allowed"
+}
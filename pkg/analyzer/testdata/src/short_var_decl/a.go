@@ -0,0 +1,13 @@
+package a
+
+import "strconv"
+
+func parse(s string) error {
+	n, err := strconv.Atoi(s) // want "Short variable declaration ':=' is not allowed"
+	if err != nil {
+		return err
+	}
+	m := n //nolint:short-var-decl
+	_ = m
+	return nil
+}
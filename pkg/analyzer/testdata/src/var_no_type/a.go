@@ -0,0 +1,7 @@
+package a
+
+import "strconv"
+
+var count = strconv.Itoa(1) // want "Variable declaration without explicit type is not allowed"
+
+var name = "go-syntax"
@@ -0,0 +1,165 @@
+// Package cache is the on-disk cache of go-syntax, mapping keys, hashes
+// of what the linting of a file depends on, to its results.
+//
+// The entries are files named after their key, written to a temporary
+// file then renamed: several processes may share a cache, each seeing
+// either a whole entry or none.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// EnvVar names the environment variable overriding the directory of the
+// cache, or disabling it with "off".
+const EnvVar = "GOSYNTAXCACHE"
+
+// Key identifies an entry.
+type Key [sha256.Size]byte
+
+// Cache is a cache directory.
+type Cache struct {
+	dir string
+}
+
+// Stats describes the content of a cache.
+type Stats struct {
+	Entries int
+	Size    int64
+}
+
+// DefaultDir returns the directory of the cache: $GOSYNTAXCACHE, or
+// go-syntax in the user cache directory. It returns the empty string when
+// $GOSYNTAXCACHE is "off".
+func DefaultDir() (string, error) {
+	var dir string
+	dir = os.Getenv(EnvVar)
+	if dir == "off" {
+		return "", nil
+	}
+	if dir != "" {
+		return dir, nil
+	}
+
+	var err error
+	dir, err = os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "go-syntax"), nil
+}
+
+// Open opens the cache in dir, creating it when needed.
+func Open(dir string) (*Cache, error) {
+	var err error
+	err = os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+	return &Cache{dir: dir}, nil
+}
+
+// Dir returns the directory of c.
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// path returns the file of the entry key, in a subdirectory named after
+// its first byte so that no directory grows too large.
+func (c *Cache) path(key Key) string {
+	var name string
+	name = hex.EncodeToString(key[:])
+	return filepath.Join(c.dir, name[:2], name)
+}
+
+// Get returns the data of the entry key, if any.
+func (c *Cache) Get(key Key) ([]byte, bool) {
+	var data []byte
+	var err error
+	data, err = os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// Put sets the data of the entry key.
+func (c *Cache) Put(key Key, data []byte) error {
+	var path string
+	path = c.path(key)
+	var err error
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+
+	var tmp *os.File
+	tmp, err = os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Close()
+	} else {
+		tmp.Close()
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// Stats returns the number of entries of c and their total size.
+func (c *Cache) Stats() (Stats, error) {
+	var stats Stats
+	var err error
+	err = filepath.WalkDir(c.dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// An entry removed meanwhile is not counted.
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".tmp-") {
+			return nil
+		}
+		var info fs.FileInfo
+		info, err = entry.Info()
+		if err != nil {
+			return nil
+		}
+		stats.Entries++
+		stats.Size += info.Size()
+		return nil
+	})
+	return stats, err
+}
+
+// Clean removes the entries of c.
+func (c *Cache) Clean() error {
+	var entries []os.DirEntry
+	var err error
+	entries, err = os.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+	var entry os.DirEntry
+	for _, entry = range entries {
+		err = os.RemoveAll(filepath.Join(c.dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
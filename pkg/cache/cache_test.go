@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	var c *Cache
+	var err error
+	c, err = Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open cache: %v", err)
+	}
+
+	var key Key
+	key = sha256.Sum256([]byte("key"))
+	var ok bool
+	_, ok = c.Get(key)
+	if ok {
+		t.Errorf("Expected no entry in an empty cache")
+	}
+
+	// Processes writing the same entry each write it whole.
+	var wg sync.WaitGroup
+	var i int
+	for i = 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var err error
+			err = c.Put(key, []byte("issues"))
+			if err != nil {
+				t.Errorf("Failed to put entry: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	err = c.Put(sha256.Sum256([]byte("other")), []byte("other issues"))
+	if err != nil {
+		t.Fatalf("Failed to put entry: %v", err)
+	}
+
+	var data []byte
+	data, ok = c.Get(key)
+	if !ok || string(data) != "issues" {
+		t.Errorf("Expected entry %q, got %q", "issues", data)
+	}
+
+	var stats Stats
+	stats, err = c.Stats()
+	if err != nil {
+		t.Fatalf("Failed to read stats: %v", err)
+	}
+	if fmt.Sprint(stats) != "{2 18}" {
+		t.Errorf("Expected 2 entries of 18 bytes, got %+v", stats)
+	}
+
+	err = c.Clean()
+	if err != nil {
+		t.Fatalf("Failed to clean cache: %v", err)
+	}
+	_, ok = c.Get(key)
+	if ok {
+		t.Errorf("Expected no entry once cleaned")
+	}
+	stats, err = c.Stats()
+	if err != nil || stats.Entries != 0 {
+		t.Errorf("Expected no entries once cleaned, got %+v, %v", stats, err)
+	}
+}
+
+func TestDefaultDir(t *testing.T) {
+	var dir string
+	var err error
+	t.Setenv(EnvVar, "/tmp/somewhere")
+	dir, err = DefaultDir()
+	if err != nil || dir != "/tmp/somewhere" {
+		t.Errorf("Expected the directory of %s, got %q, %v", EnvVar, dir, err)
+	}
+	t.Setenv(EnvVar, "off")
+	dir, err = DefaultDir()
+	if err != nil || dir != "" {
+		t.Errorf("Expected no directory when disabled, got %q, %v", dir, err)
+	}
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/thierry-f-78/go-syntax/pkg/cache"
+)
+
+const cacheUsage string = `Usage: go-syntax cache clean|stats
+
+clean removes the results kept by the previous runs, stats prints their
+number and size. The cache is in $` + cache.EnvVar + `, or go-syntax in the user
+cache directory; ` + cache.EnvVar + `=off disables it.
+`
+
+// runCache runs the cache subcommand and returns the exit code.
+func runCache(args []string) int {
+	if len(args) != 1 || (args[0] != "clean" && args[0] != "stats") {
+		fmt.Fprint(os.Stderr, cacheUsage)
+		return 2
+	}
+
+	var c *cache.Cache
+	var err error
+	c, err = openCache()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening cache: %v\n", err)
+		return 1
+	}
+	if c == nil {
+		fmt.Fprintf(os.Stderr, "The cache is disabled by %s=off\n", cache.EnvVar)
+		return 1
+	}
+
+	if args[0] == "clean" {
+		err = c.Clean()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error cleaning cache: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	var stats cache.Stats
+	stats, err = c.Stats()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading cache: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Directory: %s\n", c.Dir())
+	fmt.Printf("Entries:   %d\n", stats.Entries)
+	fmt.Printf("Size:      %d bytes\n", stats.Size)
+	return 0
+}
+
+// openCache opens the cache of the environment, nil when disabled.
+func openCache() (*cache.Cache, error) {
+	var dir string
+	var err error
+	dir, err = cache.DefaultDir()
+	if err != nil || dir == "" {
+		return nil, err
+	}
+	return cache.Open(dir)
+}
@@ -0,0 +1,335 @@
+// Package cli is the go-syntax command. A module shipping its own rules
+// builds its own command with them registered:
+//
+//	package main
+//
+//	import (
+//		"github.com/thierry-f-78/go-syntax/pkg/cli"
+//
+//		_ "example.com/team/lintrules" // calls rules.Register
+//	)
+//
+//	func main() {
+//		cli.Main()
+//	}
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/thierry-f-78/go-syntax/pkg/cache"
+	"github.com/thierry-f-78/go-syntax/pkg/config"
+	"github.com/thierry-f-78/go-syntax/pkg/linter"
+	"github.com/thierry-f-78/go-syntax/pkg/loader"
+	"github.com/thierry-f-78/go-syntax/pkg/report"
+	"github.com/thierry-f-78/go-syntax/pkg/rules"
+	"github.com/thierry-f-78/go-syntax/pkg/types"
+)
+
+// stringSlice implements flag.Value for multiple string flags
+type stringSlice []string
+
+func (s *stringSlice) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringSlice) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// isExcluded checks if a file path matches any of the exclude patterns
+func isExcluded(filePath string, excludePatterns []string) bool {
+	var pattern string
+	for _, pattern = range excludePatterns {
+		var matched bool
+		var err error
+		matched, err = filepath.Match(pattern, filepath.Base(filePath))
+		if err == nil && matched {
+			return true
+		}
+
+		// Also try matching the full path
+		matched, err = filepath.Match(pattern, filePath)
+		if err == nil && matched {
+			return true
+		}
+
+		// Check if pattern matches any part of the path
+		if strings.Contains(filePath, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitTags splits the value of -tags, a comma or space separated list.
+func splitTags(tags string) []string {
+	return strings.FieldsFunc(tags, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+}
+
+// excludeFiles removes from pkg the files excluded says so.
+func excludeFiles(pkg *loader.Package, excluded func(file string) (bool, error)) error {
+	var lists []*[]string
+	lists = []*[]string{&pkg.GoFiles, &pkg.TestGoFiles, &pkg.XTestGoFiles}
+	var list *[]string
+	for _, list = range lists {
+		var kept []string
+		var file string
+		for _, file = range *list {
+			var skip bool
+			var err error
+			skip, err = excluded(file)
+			if err != nil {
+				return err
+			}
+			if !skip {
+				kept = append(kept, file)
+			}
+		}
+		*list = kept
+	}
+	return nil
+}
+
+// sortIssues orders issues by file name, then by decreasing line.
+func sortIssues(issues []types.Issue) {
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].File != issues[j].File {
+			return issues[i].File < issues[j].File // File name alpha sort
+		}
+		return issues[i].Line > issues[j].Line // File line dec
+	})
+}
+
+// Main runs the command with the arguments of the process, and exits.
+func Main() {
+	var l *linter.Linter
+	var resolver *config.Resolver
+	var files []string
+	var err error
+	var issues []types.Issue
+
+	var verbose *bool = flag.Bool("v", false, "Verbose output")
+	var exitCode *int = flag.Int("exit-code", 1, "Exit code when issues are found")
+	var color *bool = flag.Bool("c", true, "Color output")
+	var fixMode *bool = flag.Bool("fix", false, "Apply the automatic fixes before reporting the remaining issues")
+	var diffMode *bool = flag.Bool("diff", false, "Print the automatic fixes as a unified diff instead of applying them")
+	var dryRun *bool = flag.Bool("dry-run", false, "List the automatic fixes without applying them")
+	var interactive *bool = flag.Bool("interactive", false, "Ask before applying each automatic fix (with -fix)")
+	var configFile *string = flag.String("config", "", "Configuration file to use instead of the "+config.FileName+" files found above the paths")
+	var tags *string = flag.String("tags", "", "Comma-separated list of build tags to consider satisfied")
+	var jobs *int = flag.Int("j", runtime.GOMAXPROCS(0), "Number of files linted in parallel")
+	var stdin *bool = flag.Bool("stdin", false, "Lint the source read from the standard input instead of packages (with -stdin-filename)")
+	var stdinFilename *string = flag.String("stdin-filename", "", "Path of the source read with -stdin, giving its package and configuration")
+
+	var format *string = flag.String("format", "", "Output format: "+strings.Join(report.Names(), ", ")+" (default github in GitHub Actions, gitlab in GitLab CI, text otherwise), or template=text")
+	var templateFile *string = flag.String("template-file", "", "File of the template of the template format")
+
+	var listRules *bool = flag.Bool("list-rules", false, "List the available rules and exit")
+
+	var excludePatterns stringSlice
+	flag.Var(&excludePatterns, "e", "Exclude files matching pattern (can be repeated)")
+
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		os.Exit(runConfig(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "cache" {
+		os.Exit(runCache(os.Args[2:]))
+	}
+
+	flag.Parse()
+
+	if *listRules {
+		printRules(os.Stdout)
+		return
+	}
+
+	var fixing bool = *fixMode || *diffMode || *dryRun
+	if *interactive && (!*fixMode || *diffMode || *dryRun) {
+		fmt.Fprintf(os.Stderr, "-interactive requires -fix and cannot be used with -diff or -dry-run\n")
+		os.Exit(2)
+	}
+	if *stdin && (*stdinFilename == "" || flag.NArg() > 0) {
+		fmt.Fprintf(os.Stderr, "-stdin requires -stdin-filename and no paths\n")
+		os.Exit(2)
+	}
+	if *stdin && fixing {
+		fmt.Fprintf(os.Stderr, "-stdin cannot be used with -fix, -diff or -dry-run\n")
+		os.Exit(2)
+	}
+
+	// Use command line arguments as package patterns, default to "." if none provided
+	var paths []string = flag.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	resolver = &config.Resolver{Explicit: *configFile}
+	l = linter.NewWithResolver(resolver)
+
+	// Without a cache, everything is linted.
+	var c *cache.Cache
+	c, err = openCache()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: cache disabled: %v\n", err)
+	}
+	if c != nil {
+		l.SetCache(c)
+	}
+
+	// Load the packages, then drop the excluded files
+	var loadConfig *loader.Config
+	loadConfig = &loader.Config{Tags: splitTags(*tags)}
+	var pkgs []*loader.Package
+	if *stdin {
+		// The source stands for the file, excluded or not.
+		files = []string{*stdinFilename}
+	} else {
+		pkgs, err = loadConfig.Load(paths...)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading packages: %v\n", err)
+		os.Exit(1)
+	}
+
+	var pkg *loader.Package
+	for _, pkg = range pkgs {
+		err = excludeFiles(pkg, func(file string) (bool, error) {
+			// Exclude patterns of the configuration of the file's directory
+			var cfg *config.Config
+			var err error
+			cfg, err = resolver.Resolve(filepath.Dir(file))
+			if err != nil {
+				return false, err
+			}
+			return isExcluded(file, excludePatterns) || isExcluded(file, configExcludes(cfg)), nil
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+			os.Exit(1)
+		}
+		files = append(files, pkg.Files()...)
+	}
+
+	// Output settings come from the configuration of the first path
+	var cfg *config.Config
+	var outputDir string = "."
+	if len(pkgs) > 0 {
+		outputDir = pkgs[0].Dir
+	}
+	if *stdin {
+		outputDir = filepath.Dir(*stdinFilename)
+	}
+	cfg, err = resolver.Resolve(outputDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		os.Exit(1)
+	}
+	applyOutput(cfg.Output, verbose, color, exitCode)
+	var options report.Options
+	options = report.Options{Color: *color, Verbose: *verbose}
+	if *templateFile != "" {
+		if *format != "" && *format != "template" {
+			fmt.Fprintf(os.Stderr, "-template-file requires the template format\n")
+			os.Exit(2)
+		}
+		*format = "template"
+		var data []byte
+		data, err = os.ReadFile(*templateFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading the template: %v\n", err)
+			os.Exit(2)
+		}
+		options.Template = string(data)
+	}
+	if *format == "" {
+		*format = report.Detect()
+	}
+	var formatter report.Formatter
+	formatter, err = report.New(*format, options)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(2)
+	}
+
+	l.SetBuildContext(loadConfig.Context())
+	// The issues to fix are those not silenced.
+	l.SetFixes(fixing || report.NeedsFixes(formatter))
+	l.SetSuppressed(!fixing && report.NeedsSuppressed(formatter))
+	l.SetWorkers(*jobs)
+	if *stdin {
+		var src []byte
+		src, err = io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading the standard input: %v\n", err)
+			os.Exit(1)
+		}
+		// The report quotes the source read, not the file on disk.
+		l.SetOverlay(map[string][]byte{*stdinFilename: src})
+		issues = l.LintSource(*stdinFilename, src)
+	} else {
+		issues = l.LintPackages(pkgs)
+	}
+	sortIssues(issues)
+
+	if fixing {
+		if *interactive {
+			issues, err = reviewFixes(issues, l.ReadFile, os.Stdin, os.Stdout)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reviewing fixes: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		var fixed int
+		fixed, err = fixIssues(l, issues, *diffMode, *dryRun)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error fixing files: %v\n", err)
+			os.Exit(1)
+		}
+		if *diffMode || *dryRun {
+			if fixed > 0 {
+				os.Exit(*exitCode)
+			}
+			return
+		}
+		if *verbose {
+			fmt.Printf("Fixed %d files\n", fixed)
+		}
+
+		// Report what the fixes left.
+		l.SetFixes(report.NeedsFixes(formatter))
+		l.SetSuppressed(report.NeedsSuppressed(formatter))
+		issues = l.LintPackages(pkgs)
+		sortIssues(issues)
+	}
+
+	err = formatter.Format(os.Stdout, &report.Report{
+		Version:  linter.Version(),
+		Files:    files,
+		Rules:    rules.All(),
+		Issues:   issues,
+		ReadFile: l.ReadFile,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing the report: %v\n", err)
+		os.Exit(1)
+	}
+
+	var issue types.Issue
+	for _, issue = range issues {
+		if !issue.Suppressed {
+			os.Exit(*exitCode)
+		}
+	}
+}
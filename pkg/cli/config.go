@@ -0,0 +1,174 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/thierry-f-78/go-syntax/pkg/config"
+	"github.com/thierry-f-78/go-syntax/pkg/linter"
+	"github.com/thierry-f-78/go-syntax/pkg/rules"
+)
+
+const configUsage string = `Usage: go-syntax config show [-config file] [path ...]
+
+Print the effective configuration of each path, and where each setting
+comes from.
+`
+
+// runConfig runs the config subcommand and returns the exit code.
+func runConfig(args []string) int {
+	if len(args) == 0 || args[0] != "show" {
+		fmt.Fprint(os.Stderr, configUsage)
+		return 2
+	}
+
+	var flags *flag.FlagSet
+	flags = flag.NewFlagSet("config show", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(os.Stderr, configUsage)
+		flags.PrintDefaults()
+	}
+	var configFile *string = flags.String("config", "", "Configuration file to use instead of the "+config.FileName+" files found above the paths")
+	var err error
+	err = flags.Parse(args[1:])
+	if err != nil {
+		return 2
+	}
+
+	var paths []string = flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	var resolver *config.Resolver
+	resolver = &config.Resolver{Explicit: *configFile}
+	var path string
+	for _, path = range paths {
+		var cfg *config.Config
+		cfg, err = resolver.Resolve(configDir(path))
+		if err == nil {
+			_, err = linter.NewFromConfig(cfg)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+			return 1
+		}
+		showConfig(os.Stdout, path, cfg)
+	}
+	return 0
+}
+
+// configDir returns the directory whose configuration applies to path.
+func configDir(path string) string {
+	var info os.FileInfo
+	var err error
+	info, err = os.Stat(path)
+	if err == nil && !info.IsDir() {
+		return filepath.Dir(path)
+	}
+	return path
+}
+
+// showConfig prints the effective configuration cfg of path, every
+// setting followed by its origin.
+func showConfig(out io.Writer, path string, cfg *config.Config) {
+	fmt.Fprintf(out, "# %s\n", path)
+	var file string
+	for _, file = range cfg.Files {
+		fmt.Fprintf(out, "# from %s\n", file)
+	}
+	if len(cfg.Files) == 0 {
+		fmt.Fprintf(out, "# no configuration file\n")
+	}
+
+	fmt.Fprintf(out, "rules:\n")
+	var info rules.Info
+	for _, info = range rules.All() {
+		var enabled bool = info.Enabled
+		var origin string = "default"
+		var options []config.Option
+		var ruleConfig config.RuleConfig
+		for _, ruleConfig = range cfg.Rules {
+			if ruleConfig.Name != info.Name {
+				continue
+			}
+			if ruleConfig.Enabled != nil {
+				enabled = ruleConfig.Enabled.Value
+				origin = ruleConfig.Enabled.Origin.String()
+			}
+			options = ruleConfig.Options
+		}
+
+		fmt.Fprintf(out, "  %s:\n", info.Name)
+		fmt.Fprintf(out, "    enabled: %t  # %s\n", enabled, origin)
+		var optionInfo rules.OptionInfo
+		for _, optionInfo = range info.Options {
+			var value interface{} = optionInfo.Default
+			origin = "default"
+			var option config.Option
+			for _, option = range options {
+				if option.Name == optionInfo.Name {
+					value = option.Value
+					origin = option.Origin.String()
+				}
+			}
+			fmt.Fprintf(out, "    %s: %v  # %s\n", optionInfo.Name, value, origin)
+		}
+	}
+
+	if len(cfg.Exclude) > 0 {
+		fmt.Fprintf(out, "exclude:\n")
+		var exclude config.Setting[string]
+		for _, exclude = range cfg.Exclude {
+			fmt.Fprintf(out, "  - %q  # %s\n", exclude.Value, exclude.Origin)
+		}
+	}
+
+	fmt.Fprintf(out, "output:\n")
+	showSetting(out, "color", cfg.Output.Color, "c")
+	showSetting(out, "verbose", cfg.Output.Verbose, "v")
+	showSetting(out, "exit-code", cfg.Output.ExitCode, "exit-code")
+}
+
+// showSetting prints the output setting name, or the default of its
+// command line flag when it is not configured.
+func showSetting[T any](out io.Writer, name string, setting *config.Setting[T], flagName string) {
+	if setting == nil {
+		fmt.Fprintf(out, "  %s: %s  # default\n", name, flag.Lookup(flagName).DefValue)
+		return
+	}
+	fmt.Fprintf(out, "  %s: %v  # %s\n", name, setting.Value, setting.Origin)
+}
+
+// configExcludes returns the exclude patterns of cfg.
+func configExcludes(cfg *config.Config) []string {
+	var patterns []string
+	var exclude config.Setting[string]
+	for _, exclude = range cfg.Exclude {
+		patterns = append(patterns, exclude.Value)
+	}
+	return patterns
+}
+
+// applyOutput applies the output settings of cfg to the flags not given
+// on the command line.
+func applyOutput(output config.Output, verbose *bool, color *bool, exitCode *int) {
+	var set map[string]bool
+	set = make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	if output.Verbose != nil && !set["v"] {
+		*verbose = output.Verbose.Value
+	}
+	if output.Color != nil && !set["c"] {
+		*color = output.Color.Value
+	}
+	if output.ExitCode != nil && !set["exit-code"] {
+		*exitCode = output.ExitCode.Value
+	}
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/thierry-f-78/go-syntax/pkg/fix"
+	"github.com/thierry-f-78/go-syntax/pkg/linter"
+	"github.com/thierry-f-78/go-syntax/pkg/types"
+)
+
+// fixIssues applies the suggested fixes of issues with l. The files are
+// written in place, once they type-check, or printed as a unified diff
+// when diff is set, or the fixes only listed when dryRun is set. Issues left unfixed are reported
+// on stderr. It returns the number of files changed.
+func fixIssues(l *linter.Linter, issues []types.Issue, diff bool, dryRun bool) (int, error) {
+	var sorted []types.Issue
+	sorted = append(sorted, issues...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].File != sorted[j].File {
+			return sorted[i].File < sorted[j].File
+		}
+		if sorted[i].Line != sorted[j].Line {
+			return sorted[i].Line < sorted[j].Line
+		}
+		return sorted[i].Column < sorted[j].Column
+	})
+
+	var issue types.Issue
+	for _, issue = range sorted {
+		if issue.NoFixReason != "" {
+			printNotFixed(issue, issue.NoFixReason)
+		}
+	}
+
+	var results []linter.FixResult
+	var err error
+	results, err = l.ApplyFixes(sorted)
+	if err != nil {
+		return 0, err
+	}
+
+	var changed int
+	var result linter.FixResult
+	for _, result = range results {
+		for _, issue = range result.Skipped {
+			printNotFixed(issue, "the fix conflicts with another one, run again")
+		}
+		if bytes.Equal(result.Before, result.After) {
+			continue
+		}
+		changed++
+
+		if diff {
+			fmt.Print(fix.Diff(filepath.ToSlash(result.File), result.Before, result.After))
+		} else if dryRun {
+			for _, issue = range result.Applied {
+				fmt.Printf("%s:%d:%d: [%s] would fix: %s\n", issue.File, issue.Line, issue.Column, issue.Rule, issue.Fix.Description)
+			}
+		}
+	}
+
+	if !diff && !dryRun {
+		err = l.CheckFixes(results)
+		if err != nil {
+			return 0, err
+		}
+		err = linter.WriteFixes(results)
+		if err != nil {
+			return changed, err
+		}
+	}
+
+	return changed, nil
+}
+
+func printNotFixed(issue types.Issue, reason string) {
+	fmt.Fprintf(os.Stderr, "%s:%d:%d: [%s] not fixed: %s\n", issue.File, issue.Line, issue.Column, issue.Rule, reason)
+}
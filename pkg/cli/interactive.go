@@ -0,0 +1,227 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/thierry-f-78/go-syntax/pkg/fix"
+	"github.com/thierry-f-78/go-syntax/pkg/types"
+)
+
+// snippetContext is the number of source lines shown around an issue.
+const snippetContext int = 2
+
+const reviewPrompt string = "Apply this fix [y,n,a,q,?]? "
+
+const reviewHelp string = `y - apply this fix
+n - do not apply this fix
+a - apply this fix and all the remaining fixes of the same rule
+q - quit; do not apply this fix or any of the remaining ones
+? - print help
+`
+
+// reviewFixes walks issues in order and asks on out, for each one having
+// a suggested fix, whether to apply it. The files are read with readFile,
+// as the linter did. Answers are single keys read from in, without waiting
+// for Enter when in is a terminal. The issues are returned with the fixes
+// that were not accepted removed; the fixes accepted before quitting are
+// kept.
+func reviewFixes(issues []types.Issue, readFile func(string) ([]byte, error), in io.Reader, out io.Writer) ([]types.Issue, error) {
+	var keys *keyReader
+	keys = newKeyReader(in)
+
+	var sources map[string][]byte
+	sources = make(map[string][]byte)
+
+	// Several issues can share a fix, such as the named results and the
+	// naked returns of a function: it is only asked for once.
+	var decided map[string]bool
+	decided = make(map[string]bool)
+	var allRules map[string]bool
+	allRules = make(map[string]bool)
+	var quit bool
+
+	var reviewed []types.Issue
+	var issue types.Issue
+	for _, issue = range issues {
+		if issue.Fix == nil {
+			reviewed = append(reviewed, issue)
+			continue
+		}
+
+		var accept bool
+		var known bool
+		accept, known = decided[fixKey(issue)]
+		switch {
+		case known:
+		case quit:
+			accept = false
+		case allRules[issue.Rule]:
+			accept = true
+		default:
+			var src []byte
+			var ok bool
+			src, ok = sources[issue.File]
+			if !ok {
+				var err error
+				src, err = readFile(issue.File)
+				if err != nil {
+					return nil, err
+				}
+				sources[issue.File] = src
+			}
+
+			var err error
+			err = showFix(out, issue, src)
+			if err != nil {
+				return nil, err
+			}
+
+			var answer string
+			answer, err = ask(keys, out)
+			if err != nil {
+				return nil, err
+			}
+			switch answer {
+			case "y":
+				accept = true
+			case "a":
+				accept = true
+				allRules[issue.Rule] = true
+			case "q":
+				quit = true
+			}
+		}
+
+		decided[fixKey(issue)] = accept
+		if !accept {
+			issue.Fix = nil
+		}
+		reviewed = append(reviewed, issue)
+	}
+
+	return reviewed, nil
+}
+
+// showFix prints issue, the source lines around it and the diff of its
+// fix.
+func showFix(out io.Writer, issue types.Issue, src []byte) error {
+	var after []byte
+	var err error
+	after, err = fix.Apply(src, issue.Fix.Edits)
+	if err != nil {
+		return fmt.Errorf("%s: %w", issue.File, err)
+	}
+
+	fmt.Fprintf(out, "\n%s:%d:%d: [%s] %s\n", issue.File, issue.Line, issue.Column, issue.Rule, issue.Message)
+
+	var lines []string
+	lines = strings.Split(string(src), "\n")
+	var line int
+	for line = issue.Line - snippetContext; line <= issue.Line+snippetContext; line++ {
+		if line < 1 || line > len(lines) {
+			continue
+		}
+		var marker string = " "
+		if line == issue.Line {
+			marker = ">"
+		}
+		fmt.Fprintf(out, "%s %4d | %s\n", marker, line, lines[line-1])
+	}
+
+	fmt.Fprintf(out, "%s:\n%s", issue.Fix.Description, fix.Diff(filepath.ToSlash(issue.File), src, after))
+	return nil
+}
+
+// fixKey identifies the fix of issue by its edits, the same for the
+// issues sharing it.
+func fixKey(issue types.Issue) string {
+	var key strings.Builder
+	key.WriteString(issue.File)
+	var edit types.TextEdit
+	for _, edit = range issue.Fix.Edits {
+		fmt.Fprintf(&key, "\x00%d:%d:%q", edit.Start, edit.End, edit.NewText)
+	}
+	return key.String()
+}
+
+// keyReader reads keys one at a time, switching the terminal to raw mode
+// while waiting for one.
+type keyReader struct {
+	reader *bufio.Reader
+	// fd is the descriptor of the terminal, -1 if the input is not one.
+	fd int
+}
+
+func newKeyReader(in io.Reader) *keyReader {
+	var keys *keyReader
+	keys = &keyReader{reader: bufio.NewReader(in), fd: -1}
+	var f *os.File
+	var ok bool
+	f, ok = in.(*os.File)
+	if ok && term.IsTerminal(int(f.Fd())) {
+		keys.fd = int(f.Fd())
+	}
+	return keys
+}
+
+// readKey returns the next key. The control keys ending the input, such
+// as Ctrl-C and Ctrl-D, come as io.EOF.
+func (k *keyReader) readKey() (byte, error) {
+	if k.fd >= 0 {
+		var state *term.State
+		var err error
+		state, err = term.MakeRaw(k.fd)
+		if err == nil {
+			defer term.Restore(k.fd, state)
+		}
+	}
+
+	var key byte
+	var err error
+	key, err = k.reader.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	if key == 0x03 || key == 0x04 {
+		return 0, io.EOF
+	}
+	return key, nil
+}
+
+// ask prompts for an answer until a valid key is given. The end of the
+// input counts as quitting. The blanks, such as the line ends of a piped
+// input, are skipped.
+func ask(keys *keyReader, out io.Writer) (string, error) {
+	fmt.Fprint(out, reviewPrompt)
+	for {
+		var key byte
+		var err error
+		key, err = keys.readKey()
+		if err == io.EOF {
+			fmt.Fprintln(out)
+			return "q", nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		var answer string
+		answer = strings.ToLower(string(rune(key)))
+		if strings.TrimSpace(answer) == "" {
+			continue
+		}
+		fmt.Fprintln(out, answer)
+		switch answer {
+		case "y", "n", "a", "q":
+			return answer, nil
+		}
+		fmt.Fprint(out, reviewHelp+reviewPrompt)
+	}
+}
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/thierry-f-78/go-syntax/pkg/rules"
+)
+
+// printRules lists the registered rules by category, with their default
+// state and options.
+func printRules(out io.Writer) {
+	var all []rules.Info
+	all = rules.All()
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].Category < all[j].Category
+	})
+
+	var category string
+	var info rules.Info
+	for _, info = range all {
+		if info.Category != category {
+			category = info.Category
+			fmt.Fprintf(out, "%s:\n", category)
+		}
+
+		var state string = "enabled"
+		if !info.Enabled {
+			state = "disabled"
+		}
+		fmt.Fprintf(out, "  %-16s %-8s  %s\n", info.Name, state, info.Description)
+
+		var option rules.OptionInfo
+		for _, option = range info.Options {
+			fmt.Fprintf(out, "      %s (%s, default %v): %s\n", option.Name, option.Type, option.Default, option.Description)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+// Package config reads the .go-syntax.yml configuration files:
+//
+//	root: false                 # true stops the inheritance from parents
+//	rules:
+//	  named-returns: false      # disable a rule
+//	  if-init:
+//	    allow-err-check: true   # rule options, the rule staying enabled
+//	  naked-return:
+//	    enabled: true
+//	    max-func-lines: 5
+//	exclude:
+//	  - "*.pb.go"
+//	output:
+//	  color: false
+//	  verbose: false
+//	  exit-code: 1
+//
+// A directory inherits the configuration of its parent directories, the
+// files closest to it overriding the others, like .editorconfig files.
+//
+// Errors, including unknown keys, are reported with the file and line
+// they come from.
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the configuration files.
+const FileName string = ".go-syntax.yml"
+
+// Config is the content of a configuration file, or the merged content
+// of several. Settings left out are nil.
+type Config struct {
+	// Files are the files the settings come from, outermost first.
+	Files   []string
+	Root    bool
+	Rules   []RuleConfig
+	Exclude []Setting[string]
+	Output  Output
+}
+
+// Origin is the place a setting comes from.
+type Origin struct {
+	File string
+	Line int
+}
+
+func (o Origin) String() string {
+	return fmt.Sprintf("%s:%d", o.File, o.Line)
+}
+
+// Setting is a configured value along with its origin.
+type Setting[T any] struct {
+	Value  T
+	Origin Origin
+}
+
+// RuleConfig is the configuration of one rule. Origin is where the rule
+// is named last.
+type RuleConfig struct {
+	Name    string
+	Origin  Origin
+	Enabled *Setting[bool]
+	Options []Option
+}
+
+// Option is a rule option. Value is a bool, an int, a float64, a string,
+// or a []interface{} of those.
+type Option struct {
+	Name   string
+	Origin Origin
+	Value  interface{}
+}
+
+// Output holds the output settings.
+type Output struct {
+	Color    *Setting[bool]
+	Verbose  *Setting[bool]
+	ExitCode *Setting[int]
+}
+
+// Error is an error located in a configuration file.
+type Error struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Message)
+}
+
+// Load reads the configuration file filename.
+func Load(filename string) (*Config, error) {
+	var data []byte
+	var err error
+	data, err = os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(filename, data)
+}
+
+// Parse parses the content of the configuration file filename.
+func Parse(filename string, data []byte) (*Config, error) {
+	var cfg *Config
+	cfg = &Config{Files: []string{filename}}
+
+	var doc yaml.Node
+	var decoder *yaml.Decoder
+	decoder = yaml.NewDecoder(bytes.NewReader(data))
+	var err error
+	err = decoder.Decode(&doc)
+	if err == io.EOF {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, syntaxError(filename, err)
+	}
+
+	var root *yaml.Node
+	root = doc.Content[0]
+	if root.Kind == yaml.ScalarNode && root.Tag == "!!null" {
+		return cfg, nil
+	}
+
+	var p parser
+	p = parser{file: filename}
+	err = p.mapping(root, func(key *yaml.Node, value *yaml.Node) error {
+		switch key.Value {
+		case "root":
+			return p.scalar(value, "!!bool", "a boolean", &cfg.Root)
+		case "rules":
+			return p.mapping(value, func(key *yaml.Node, value *yaml.Node) error {
+				var rule RuleConfig
+				var err error
+				rule, err = p.rule(key, value)
+				cfg.Rules = append(cfg.Rules, rule)
+				return err
+			})
+		case "exclude":
+			return p.strings(value, &cfg.Exclude)
+		case "output":
+			return p.output(value, &cfg.Output)
+		}
+		return p.errorf(key, "unknown key %q", key.Value)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// yamlError matches the syntax errors of the YAML decoder.
+var yamlError *regexp.Regexp = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// syntaxError returns err, a YAML syntax error, located in filename.
+func syntaxError(filename string, err error) error {
+	var match []string
+	match = yamlError.FindStringSubmatch(err.Error())
+	if match == nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+	var line int
+	line, _ = strconv.Atoi(match[1])
+	return &Error{File: filename, Line: line, Message: match[2]}
+}
+
+// parser decodes the nodes of a configuration file.
+type parser struct {
+	file string
+}
+
+func (p *parser) errorf(node *yaml.Node, format string, args ...interface{}) error {
+	return &Error{File: p.file, Line: node.Line, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) origin(node *yaml.Node) Origin {
+	return Origin{File: p.file, Line: node.Line}
+}
+
+// mapping calls fn for each key and value of the mapping node, in order.
+func (p *parser) mapping(node *yaml.Node, fn func(key *yaml.Node, value *yaml.Node) error) error {
+	if node.Kind != yaml.MappingNode {
+		return p.errorf(node, "expected a mapping")
+	}
+
+	var seen map[string]bool
+	seen = make(map[string]bool)
+	var i int
+	for i = 0; i+1 < len(node.Content); i += 2 {
+		var key *yaml.Node
+		key = node.Content[i]
+		if seen[key.Value] {
+			return p.errorf(key, "duplicate key %q", key.Value)
+		}
+		seen[key.Value] = true
+
+		var err error
+		err = fn(key, node.Content[i+1])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rule decodes the configuration of a rule: a boolean enabling or
+// disabling it, or a mapping of its options, "enabled" included.
+func (p *parser) rule(key *yaml.Node, value *yaml.Node) (RuleConfig, error) {
+	var rule RuleConfig
+	rule = RuleConfig{Name: key.Value, Origin: p.origin(key)}
+
+	if value.Kind == yaml.ScalarNode {
+		rule.Enabled = &Setting[bool]{Origin: p.origin(key)}
+		return rule, p.scalar(value, "!!bool", "a boolean or a mapping", &rule.Enabled.Value)
+	}
+
+	var err error
+	err = p.mapping(value, func(key *yaml.Node, value *yaml.Node) error {
+		if key.Value == "enabled" {
+			rule.Enabled = &Setting[bool]{Origin: p.origin(key)}
+			return p.scalar(value, "!!bool", "a boolean", &rule.Enabled.Value)
+		}
+
+		var option Option
+		option = Option{Name: key.Value, Origin: p.origin(key)}
+		var err error
+		err = value.Decode(&option.Value)
+		if err != nil {
+			return p.errorf(value, "%v", err)
+		}
+		rule.Options = append(rule.Options, option)
+		return nil
+	})
+	return rule, err
+}
+
+func (p *parser) output(node *yaml.Node, output *Output) error {
+	return p.mapping(node, func(key *yaml.Node, value *yaml.Node) error {
+		switch key.Value {
+		case "color":
+			output.Color = &Setting[bool]{Origin: p.origin(key)}
+			return p.scalar(value, "!!bool", "a boolean", &output.Color.Value)
+		case "verbose":
+			output.Verbose = &Setting[bool]{Origin: p.origin(key)}
+			return p.scalar(value, "!!bool", "a boolean", &output.Verbose.Value)
+		case "exit-code":
+			output.ExitCode = &Setting[int]{Origin: p.origin(key)}
+			return p.scalar(value, "!!int", "an integer", &output.ExitCode.Value)
+		}
+		return p.errorf(key, "unknown key %q", key.Value)
+	})
+}
+
+// strings appends the strings of the sequence node to list.
+func (p *parser) strings(node *yaml.Node, list *[]Setting[string]) error {
+	if node.Kind != yaml.SequenceNode {
+		return p.errorf(node, "expected a list of strings")
+	}
+
+	var item *yaml.Node
+	for _, item = range node.Content {
+		var s Setting[string]
+		s.Origin = p.origin(item)
+		var err error
+		err = p.scalar(item, "!!str", "a string", &s.Value)
+		if err != nil {
+			return err
+		}
+		*list = append(*list, s)
+	}
+	return nil
+}
+
+// scalar decodes the scalar node of type tag into dst.
+func (p *parser) scalar(node *yaml.Node, tag string, expected string, dst interface{}) error {
+	if node.Kind != yaml.ScalarNode || node.Tag != tag {
+		return p.errorf(node, "expected %s", expected)
+	}
+	var err error
+	err = node.Decode(dst)
+	if err != nil {
+		return p.errorf(node, "%v", err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	var cfg *Config
+	var err error
+	cfg, err = Parse(".go-syntax.yml", []byte(`rules:
+  named-returns: false
+  if-init:
+    allow-err-check: true
+  naked-return:
+    enabled: true
+    max-func-lines: 5
+exclude:
+  - "*.pb.go"
+output:
+  color: false
+  exit-code: 3
+`))
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	if len(cfg.Rules) != 3 {
+		t.Fatalf("Expected 3 rules, got %d", len(cfg.Rules))
+	}
+	if cfg.Rules[0].Name != "named-returns" || cfg.Rules[0].Enabled == nil || cfg.Rules[0].Enabled.Value {
+		t.Errorf("Expected named-returns to be disabled, got %+v", cfg.Rules[0])
+	}
+	if cfg.Rules[1].Enabled != nil || len(cfg.Rules[1].Options) != 1 || cfg.Rules[1].Options[0].Value != true {
+		t.Errorf("Expected the allow-err-check option alone, got %+v", cfg.Rules[1])
+	}
+	if cfg.Rules[2].Options[0].Name != "max-func-lines" || cfg.Rules[2].Options[0].Value != 5 || cfg.Rules[2].Options[0].Origin.Line != 7 {
+		t.Errorf("Expected max-func-lines: 5 at line 7, got %+v", cfg.Rules[2].Options[0])
+	}
+	if len(cfg.Exclude) != 1 || cfg.Exclude[0].Value != "*.pb.go" {
+		t.Errorf("Unexpected exclude patterns %v", cfg.Exclude)
+	}
+	if cfg.Output.Color == nil || cfg.Output.Color.Value || cfg.Output.Verbose != nil || cfg.Output.ExitCode == nil || cfg.Output.ExitCode.Value != 3 {
+		t.Errorf("Unexpected output settings %+v", cfg.Output)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	var tests []struct {
+		name     string
+		data     string
+		expected string
+	}
+	tests = []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{
+			name:     "unknown_key",
+			data:     "rules: {}\nlinters: {}\n",
+			expected: `cfg.yml:2: unknown key "linters"`,
+		},
+		{
+			name:     "unknown_output_key",
+			data:     "output:\n  colour: true\n",
+			expected: `cfg.yml:2: unknown key "colour"`,
+		},
+		{
+			name:     "wrong_type",
+			data:     "output:\n  exit-code: high\n",
+			expected: `cfg.yml:2: expected an integer`,
+		},
+		{
+			name:     "rule_not_boolean",
+			data:     "rules:\n  if-init: off-ish\n",
+			expected: `cfg.yml:2: expected a boolean or a mapping`,
+		},
+		{
+			name:     "duplicate_key",
+			data:     "rules:\n  if-init: true\n  if-init: false\n",
+			expected: `cfg.yml:3: duplicate key "if-init"`,
+		},
+		{
+			name:     "syntax_error",
+			data:     "rules:\n  if-init: [\n",
+			expected: `cfg.yml:2: did not find expected node content`,
+		},
+	}
+
+	var tt struct {
+		name     string
+		data     string
+		expected string
+	}
+	for _, tt = range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			_, err = Parse("cfg.yml", []byte(tt.data))
+			if err == nil || err.Error() != tt.expected {
+				t.Errorf("Expected error %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
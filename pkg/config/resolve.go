@@ -0,0 +1,173 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Merge returns the configuration made of cfg overridden by child: the
+// settings of child replace those of cfg, rule by rule and option by
+// option, and the exclude patterns of both apply.
+func (cfg *Config) Merge(child *Config) *Config {
+	var merged *Config
+	merged = &Config{
+		Root:   child.Root,
+		Output: cfg.Output,
+	}
+	merged.Files = append(append(merged.Files, cfg.Files...), child.Files...)
+	merged.Exclude = append(append(merged.Exclude, cfg.Exclude...), child.Exclude...)
+
+	merged.Rules = append(merged.Rules, cfg.Rules...)
+	var rule RuleConfig
+	for _, rule = range child.Rules {
+		var i int = merged.rule(rule.Name)
+		if i < 0 {
+			merged.Rules = append(merged.Rules, rule)
+			continue
+		}
+
+		var base RuleConfig = merged.Rules[i]
+		base.Origin = rule.Origin
+		if rule.Enabled != nil {
+			base.Enabled = rule.Enabled
+		}
+		base.Options = mergeOptions(base.Options, rule.Options)
+		merged.Rules[i] = base
+	}
+
+	if child.Output.Color != nil {
+		merged.Output.Color = child.Output.Color
+	}
+	if child.Output.Verbose != nil {
+		merged.Output.Verbose = child.Output.Verbose
+	}
+	if child.Output.ExitCode != nil {
+		merged.Output.ExitCode = child.Output.ExitCode
+	}
+
+	return merged
+}
+
+// rule returns the index of the rule name in cfg.Rules, or -1.
+func (cfg *Config) rule(name string) int {
+	var i int
+	for i = range cfg.Rules {
+		if cfg.Rules[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// mergeOptions returns the options of base overridden by those of child.
+func mergeOptions(base []Option, child []Option) []Option {
+	var merged []Option
+	merged = append(merged, base...)
+
+	var option Option
+	for _, option = range child {
+		var replaced bool
+		var i int
+		for i = range merged {
+			if merged[i].Name == option.Name {
+				merged[i] = option
+				replaced = true
+			}
+		}
+		if !replaced {
+			merged = append(merged, option)
+		}
+	}
+	return merged
+}
+
+// Resolver computes the effective configuration of directories. Each
+// configuration file is read once. It is safe for concurrent use.
+type Resolver struct {
+	// Explicit, when set, is the only configuration file used, for every
+	// directory.
+	Explicit string
+
+	mu   sync.Mutex
+	dirs map[string]*Config
+}
+
+// Resolve returns the effective configuration of dir: the configuration
+// files of dir and of its parent directories merged, up to the first one
+// marked root. Directories without any configuration get an empty one.
+func (r *Resolver) Resolve(dir string) (*Config, error) {
+	var err error
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.dirs == nil {
+		r.dirs = make(map[string]*Config)
+	}
+	if r.Explicit != "" {
+		return r.explicit()
+	}
+	return r.resolve(dir)
+}
+
+func (r *Resolver) explicit() (*Config, error) {
+	var cfg *Config
+	cfg = r.dirs[""]
+	if cfg != nil {
+		return cfg, nil
+	}
+
+	var err error
+	cfg, err = Load(r.Explicit)
+	if err != nil {
+		return nil, err
+	}
+	r.dirs[""] = cfg
+	return cfg, nil
+}
+
+func (r *Resolver) resolve(dir string) (*Config, error) {
+	var cfg *Config
+	cfg = r.dirs[dir]
+	if cfg != nil {
+		return cfg, nil
+	}
+
+	var own *Config
+	var err error
+	own, err = Load(filepath.Join(dir, FileName))
+	if errors.Is(err, os.ErrNotExist) {
+		own = nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var parent string
+	parent = filepath.Dir(dir)
+	switch {
+	case own != nil && own.Root:
+		cfg = own
+	case parent == dir:
+		cfg = &Config{}
+		if own != nil {
+			cfg = own
+		}
+	default:
+		cfg, err = r.resolve(parent)
+		if err != nil {
+			return nil, err
+		}
+		if own != nil {
+			cfg = cfg.Merge(own)
+		}
+	}
+
+	r.dirs[dir] = cfg
+	return cfg, nil
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeConfigs creates the configuration files of files, by directory
+// relative to root.
+func writeConfigs(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+
+	var dir string
+	var content string
+	for dir, content = range files {
+		var err error
+		err = os.MkdirAll(filepath.Join(root, dir), 0o755)
+		if err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		err = os.WriteFile(filepath.Join(root, dir, FileName), []byte(content), 0o644)
+		if err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	var root string
+	root = t.TempDir()
+	writeConfigs(t, root, map[string]string{
+		".": `rules:
+  named-returns: true
+  naked-return:
+    max-func-lines: 5
+exclude: ["*.pb.go"]
+output:
+  color: false
+`,
+		"legacy": `rules:
+  named-returns: false
+  naked-return:
+    enabled: false
+exclude: ["gen_*.go"]
+`,
+		"legacy/isolated": `root: true
+rules:
+  if-init: false
+`,
+	})
+	var err error
+	err = os.MkdirAll(filepath.Join(root, "legacy", "svc", "api"), 0o755)
+	if err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	var resolver Resolver
+
+	var cfg *Config
+	cfg, err = resolver.Resolve(filepath.Join(root, "legacy", "svc", "api"))
+	if err != nil {
+		t.Fatalf("Failed to resolve: %v", err)
+	}
+	if len(cfg.Files) != 2 {
+		t.Errorf("Expected 2 configuration files, got %v", cfg.Files)
+	}
+	if len(cfg.Rules) != 2 {
+		t.Fatalf("Expected 2 rules, got %d", len(cfg.Rules))
+	}
+
+	var named RuleConfig = cfg.Rules[0]
+	if named.Name != "named-returns" || named.Enabled.Value || named.Enabled.Origin.File != filepath.Join(root, "legacy", FileName) {
+		t.Errorf("Expected named-returns disabled by legacy, got %+v", named)
+	}
+	var naked RuleConfig = cfg.Rules[1]
+	if naked.Enabled.Value || len(naked.Options) != 1 || naked.Options[0].Origin.File != filepath.Join(root, FileName) {
+		t.Errorf("Expected naked-return disabled with the inherited option, got %+v", naked)
+	}
+	if len(cfg.Exclude) != 2 {
+		t.Errorf("Expected the exclude patterns of both files, got %v", cfg.Exclude)
+	}
+	if cfg.Output.Color == nil || cfg.Output.Color.Value {
+		t.Errorf("Expected the inherited color setting, got %+v", cfg.Output.Color)
+	}
+
+	cfg, err = resolver.Resolve(filepath.Join(root, "legacy", "isolated"))
+	if err != nil {
+		t.Fatalf("Failed to resolve: %v", err)
+	}
+	if len(cfg.Files) != 1 || len(cfg.Rules) != 1 || cfg.Rules[0].Name != "if-init" || cfg.Output.Color != nil {
+		t.Errorf("Expected a root configuration not to inherit, got %+v", cfg)
+	}
+
+	var again *Config
+	again, err = resolver.Resolve(filepath.Join(root, "legacy", "isolated"))
+	if err != nil || again != cfg {
+		t.Errorf("Expected the resolved configuration to be cached")
+	}
+}
+
+func TestResolveExplicit(t *testing.T) {
+	var root string
+	root = t.TempDir()
+	writeConfigs(t, root, map[string]string{
+		".":   "rules:\n  if-init: false\n",
+		"sub": "rules:\n  named-returns: false\n",
+	})
+
+	var resolver Resolver
+	resolver.Explicit = filepath.Join(root, FileName)
+
+	var cfg *Config
+	var err error
+	cfg, err = resolver.Resolve(filepath.Join(root, "sub"))
+	if err != nil {
+		t.Fatalf("Failed to resolve: %v", err)
+	}
+	if len(cfg.Rules) != 1 || cfg.Rules[0].Name != "if-init" {
+		t.Errorf("Expected the explicit configuration only, got %+v", cfg.Rules)
+	}
+}
@@ -0,0 +1,288 @@
+package fix
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"github.com/thierry-f-78/go-syntax/pkg/rules"
+	syntax "github.com/thierry-f-78/go-syntax/pkg/types"
+)
+
+// ConstNoType gives an explicit type to the constant declarations of file
+// flagged by rules.ConstNoTypeRule. The type is chosen from the uses of
+// the constants in the whole package:
+//
+//	const BufferSize = 1024                 ->  const BufferSize int = 1024
+//	const Timeout = 5  // time.Duration(Timeout) everywhere
+//	                                        ->  const Timeout time.Duration = 5
+//
+// The package is type-checked again with the new type, and the fix is
+// refused when the program would no longer compile, or when the type or
+// value of any expression would change. The exported constants of
+// packages other than main are left as is, their uses out of reach.
+func ConstNoType(p *Package, file *ast.File) []Suggestion {
+	var reported map[token.Pos]bool
+	reported = flagged(p, file, (&rules.ConstNoTypeRule{}).Check(p.Fset, file))
+
+	var suggestions []Suggestion
+	var q *qualifier
+	q = newQualifier(p, file)
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		var decl *ast.GenDecl
+		var ok bool
+		decl, ok = n.(*ast.GenDecl)
+		if !ok || decl.Tok != token.CONST {
+			return true
+		}
+
+		var i int
+		var spec ast.Spec
+		for i, spec = range decl.Specs {
+			var valueSpec *ast.ValueSpec
+			valueSpec = spec.(*ast.ValueSpec)
+			if !reported[valueSpec.Pos()] {
+				continue
+			}
+
+			// The specs without values that follow repeat this one, type
+			// included: they are typed by the same fix.
+			var group []*ast.ValueSpec
+			group = append(group, valueSpec)
+			var next ast.Spec
+			for _, next = range decl.Specs[i+1:] {
+				if len(next.(*ast.ValueSpec).Values) > 0 {
+					break
+				}
+				group = append(group, next.(*ast.ValueSpec))
+			}
+
+			q.begin()
+			suggestions = suggest(suggestions, "const-no-type", "Add the explicit type of the constants", constNoTypeSpec(p, q, group), valueSpec.Pos())
+		}
+		return false
+	})
+
+	return suggestions
+}
+
+func constNoTypeSpec(p *Package, q *qualifier, group []*ast.ValueSpec) []syntax.TextEdit {
+	var spec *ast.ValueSpec
+	spec = group[0]
+
+	var objs map[types.Object]bool
+	objs = make(map[types.Object]bool)
+	var defaultType types.Type
+	var member *ast.ValueSpec
+	for _, member = range group {
+		var name *ast.Ident
+		for _, name = range member.Names {
+			var obj types.Object
+			obj = p.Info.Defs[name]
+			if obj == nil {
+				return nil
+			}
+			objs[obj] = true
+
+			// The package alone cannot tell what the other packages do
+			// with its exported constants. Those of package main cannot
+			// be imported.
+			if obj.Exported() && p.Types.Name() != "main" {
+				p.refuse(spec.Pos(), "const-no-type", "the constant "+obj.Name()+" is exported: a type could break the packages using it, as in var f float64 = pkg."+obj.Name())
+				return nil
+			}
+
+			if member != spec {
+				continue
+			}
+			if defaultType != nil && !types.Identical(types.Default(obj.Type()), defaultType) {
+				p.refuse(spec.Pos(), "const-no-type", "the constants of the declaration have different default types")
+				return nil
+			}
+			defaultType = types.Default(obj.Type())
+		}
+	}
+
+	var candidates []types.Type
+	var useTypes []types.Type
+	useTypes = constUseTypes(p, objs)
+	if len(useTypes) == 1 && !types.Identical(useTypes[0], defaultType) {
+		candidates = append(candidates, useTypes[0])
+	}
+	candidates = append(candidates, defaultType)
+
+	var reasons []string
+	var candidate types.Type
+	for _, candidate = range candidates {
+		var typ string
+		var ok bool
+		typ, ok = q.typeString(candidate, spec.Pos())
+		if !ok {
+			reasons = append(reasons, fmt.Sprintf("%s cannot be written here", candidate))
+			continue
+		}
+
+		var typeExpr ast.Expr
+		var err error
+		typeExpr, err = parser.ParseExpr(typ)
+		if err != nil {
+			reasons = append(reasons, err.Error())
+			continue
+		}
+
+		err = p.retype(spec, typeExpr)
+		if err != nil {
+			reasons = append(reasons, fmt.Sprintf("%s: %v", typ, err))
+			continue
+		}
+
+		p.constTypes[spec] = typeExpr
+		var lastName token.Pos
+		lastName = spec.Names[len(spec.Names)-1].End()
+		return append(q.commit(), syntax.TextEdit{Start: p.offset(lastName), End: p.offset(lastName), NewText: " " + typ})
+	}
+
+	var reason string
+	reason = strings.Join(reasons, "; ")
+	if len(useTypes) > 1 {
+		var names []string
+		var useType types.Type
+		for _, useType = range useTypes {
+			names = append(names, types.TypeString(useType, q.qualify))
+		}
+		reason = "its uses need incompatible types (" + strings.Join(names, ", ") + "): " + reason
+	}
+	p.refuse(spec.Pos(), "const-no-type", reason)
+	return nil
+}
+
+// constUseTypes returns the distinct types the constants objs are
+// converted to where they are used, in order of first use. Uses inside
+// other constant expressions, which stay untyped, are not counted.
+func constUseTypes(p *Package, objs map[types.Object]bool) []types.Type {
+	var idents []*ast.Ident
+	var ident *ast.Ident
+	var obj types.Object
+	for ident, obj = range p.Info.Uses {
+		if objs[obj] {
+			idents = append(idents, ident)
+		}
+	}
+	sort.Slice(idents, func(i, j int) bool {
+		return idents[i].Pos() < idents[j].Pos()
+	})
+
+	var useTypes []types.Type
+	for _, ident = range idents {
+		var t types.Type
+		t = p.Info.Types[ident].Type
+		if t == nil || isUntyped(t) {
+			continue
+		}
+
+		var known bool
+		var useType types.Type
+		for _, useType = range useTypes {
+			if types.Identical(useType, t) {
+				known = true
+			}
+		}
+		if !known {
+			useTypes = append(useTypes, t)
+		}
+	}
+	return useTypes
+}
+
+// retype type-checks the package with spec given the type typeExpr, in
+// addition to the constant types already accepted. It reports an error
+// when the package no longer compiles, or when an expression changes
+// type or constant value.
+func (p *Package) retype(spec *ast.ValueSpec, typeExpr ast.Expr) error {
+	var specs map[*ast.ValueSpec]ast.Expr
+	specs = make(map[*ast.ValueSpec]ast.Expr)
+	var other *ast.ValueSpec
+	var expr ast.Expr
+	for other, expr = range p.constTypes {
+		specs[other] = expr
+	}
+	specs[spec] = typeExpr
+
+	for other, expr = range specs {
+		other.Type = expr
+	}
+	var info *types.Info
+	var err error
+	_, info, err = p.check()
+	for other = range specs {
+		other.Type = nil
+	}
+	if err != nil {
+		return fmt.Errorf("the package would not compile: %w", err)
+	}
+
+	var before types.TypeAndValue
+	for expr, before = range p.Info.Types {
+		var after types.TypeAndValue
+		after = info.Types[expr]
+		if before.Type == nil || after.Type == nil {
+			continue
+		}
+
+		if isUntyped(before.Type) {
+			if before.Value != nil && (after.Value == nil || !constant.Compare(round(before.Value, after.Type), token.EQL, after.Value)) {
+				return fmt.Errorf("the value of %s at %s would change", types.ExprString(expr), p.Fset.Position(expr.Pos()))
+			}
+			continue
+		}
+		// The package is type-checked anew: its own types are distinct
+		// objects on both sides, compare them by name.
+		if types.TypeString(before.Type, nil) != types.TypeString(after.Type, nil) {
+			return fmt.Errorf("%s at %s would change type from %s to %s", types.ExprString(expr), p.Fset.Position(expr.Pos()), before.Type, after.Type)
+		}
+	}
+
+	return nil
+}
+
+// round returns v as stored in a value of type t: typed floating-point
+// constants lose the exact precision of untyped ones.
+func round(v constant.Value, t types.Type) constant.Value {
+	var basic *types.Basic
+	var ok bool
+	basic, ok = t.Underlying().(*types.Basic)
+	if !ok {
+		return v
+	}
+
+	switch basic.Kind() {
+	case types.Float32:
+		var f float32
+		f, _ = constant.Float32Val(constant.ToFloat(v))
+		return constant.MakeFloat64(float64(f))
+	case types.Float64:
+		var f float64
+		f, _ = constant.Float64Val(constant.ToFloat(v))
+		return constant.MakeFloat64(f)
+	case types.Complex64, types.Complex128:
+		var re float64
+		var im float64
+		re, _ = constant.Float64Val(constant.ToFloat(constant.Real(v)))
+		im, _ = constant.Float64Val(constant.ToFloat(constant.Imag(v)))
+		return constant.BinaryOp(constant.MakeFloat64(re), token.ADD, constant.MakeImag(constant.MakeFloat64(im)))
+	}
+	return v
+}
+
+func isUntyped(t types.Type) bool {
+	var basic *types.Basic
+	var ok bool
+	basic, ok = t.(*types.Basic)
+	return ok && basic.Info()&types.IsUntyped != 0
+}
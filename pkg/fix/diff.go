@@ -0,0 +1,183 @@
+package fix
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext int = 3
+
+// lineOp is one line of an edit script: ' ' kept, '-' deleted or '+'
+// inserted.
+type lineOp struct {
+	kind byte
+	text string
+}
+
+// Diff returns the unified diff turning before into after, or an empty
+// string when they are identical.
+func Diff(name string, before []byte, after []byte) string {
+	var ops []lineOp
+	ops = diffLines(splitLines(string(before)), splitLines(string(after)))
+
+	var out strings.Builder
+	var oldLine int = 1
+	var newLine int = 1
+	var i int
+	for i < len(ops) {
+		if ops[i].kind == ' ' {
+			oldLine++
+			newLine++
+			i++
+			continue
+		}
+
+		// A hunk starts diffContext lines before the change and extends
+		// while changes are close enough to share their context.
+		var start int = i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		var end int = i
+		var kept int
+		for end < len(ops) && kept <= 2*diffContext {
+			if ops[end].kind == ' ' {
+				kept++
+			} else {
+				kept = 0
+			}
+			end++
+		}
+		end -= kept
+		if end+diffContext < len(ops) {
+			end += diffContext
+		} else {
+			end = len(ops)
+		}
+
+		var oldStart int = oldLine - (i - start)
+		var newStart int = newLine - (i - start)
+		var oldCount int
+		var newCount int
+		var body strings.Builder
+		var op lineOp
+		for _, op = range ops[start:end] {
+			body.WriteString(string(op.kind) + op.text + "\n")
+			if op.kind != '+' {
+				oldCount++
+			}
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- a/%s\n+++ b/%s\n", name, name)
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount))
+		out.WriteString(body.String())
+
+		for _, op = range ops[i:end] {
+			if op.kind != '+' {
+				oldLine++
+			}
+			if op.kind != '-' {
+				newLine++
+			}
+		}
+		i = end
+	}
+
+	return out.String()
+}
+
+func hunkRange(start int, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines computes the shortest edit script between a and b with the
+// Myers algorithm.
+func diffLines(a []string, b []string) []lineOp {
+	var n int = len(a)
+	var m int = len(b)
+	var offset int = n + m + 1
+	var v []int
+	v = make([]int, 2*offset+1)
+	var trace [][]int
+
+	var d int
+	var found bool
+	for d = 0; d <= n+m && !found; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		var k int
+		for k = -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			var y int = x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+
+	var ops []lineOp
+	var x int = n
+	var y int = m
+	for d = len(trace) - 1; d >= 0; d-- {
+		v = trace[d]
+		var k int = x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		var prevX int = v[offset+prevK]
+		var prevY int = prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, lineOp{kind: ' ', text: a[x]})
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			y--
+			ops = append(ops, lineOp{kind: '+', text: b[y]})
+		} else {
+			x--
+			ops = append(ops, lineOp{kind: '-', text: a[x]})
+		}
+	}
+
+	var i int
+	for i = 0; i < len(ops)/2; i++ {
+		ops[i], ops[len(ops)-1-i] = ops[len(ops)-1-i], ops[i]
+	}
+	return ops
+}
@@ -0,0 +1,298 @@
+// Package fix computes source rewrites for the issues reported by the
+// rules in pkg/rules. Rewrites need type information, so they work on
+// type-checked packages rather than on isolated files.
+package fix
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+
+	"github.com/thierry-f-78/go-syntax/pkg/loader"
+	syntax "github.com/thierry-f-78/go-syntax/pkg/types"
+)
+
+// Suggestion is a fix of the issues reported at Positions by Rule. Its
+// edits include the imports it needs.
+//
+// The suggestions of a file are computed in order, each one assuming the
+// previous ones are applied; they never overlap, except for identical
+// edits such as a shared import.
+type Suggestion struct {
+	Rule      string
+	Positions []token.Pos
+	Fix       syntax.SuggestedFix
+}
+
+// Fixer computes the fixes of the issues of one rule in a file.
+type Fixer func(p *Package, file *ast.File) []Suggestion
+
+// Fixers maps rule names to their fixer. A fixer may also fix the issues
+// of a related rule: NamedReturns spells out the naked returns of the
+// functions it rewrites.
+var Fixers map[string]Fixer = map[string]Fixer{
+	"const-no-type":  ConstNoType,
+	"if-init":        IfInit,
+	"named-returns":  NamedReturns,
+	"short-var-decl": ShortVarDecl,
+	"var-no-type":    VarNoType,
+}
+
+// Fixable reports whether the issues of rule can have a fix.
+func Fixable(rule string) bool {
+	var ok bool
+	_, ok = Fixers[rule]
+	return ok || rule == "naked-return"
+}
+
+// Refusal explains why a reported issue was left unfixed.
+type Refusal struct {
+	Pos    token.Position
+	Rule   string
+	Reason string
+}
+
+// Package is a type-checked Go package whose files can be rewritten.
+type Package struct {
+	Fset  *token.FileSet
+	Files []*ast.File
+	Types *types.Package
+	Info  *types.Info
+
+	// Refusals lists the issues the fixers declined to fix.
+	Refusals []Refusal
+
+	importer types.Importer
+
+	// constTypes holds the types given to untyped constants by the fixes
+	// accepted so far, so that each new fix is checked along with them.
+	constTypes map[*ast.ValueSpec]ast.Expr
+
+	// hoisted holds the names declared in each block by the fixes so far.
+	hoisted map[*types.Scope]map[string]bool
+}
+
+// Apply applies edits to src and returns the gofmt-formatted result.
+// Edits must not overlap, except for identical edits which are applied
+// once.
+func Apply(src []byte, edits []syntax.TextEdit) ([]byte, error) {
+	var sorted []syntax.TextEdit
+	sorted = append(sorted, edits...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Start < sorted[j].Start
+	})
+
+	var out []byte
+	var last int
+	var applied map[syntax.TextEdit]bool
+	applied = make(map[syntax.TextEdit]bool)
+	var edit syntax.TextEdit
+	for _, edit = range sorted {
+		if applied[edit] {
+			continue
+		}
+		applied[edit] = true
+		if edit.Start < last || edit.End < edit.Start || edit.End > len(src) {
+			return nil, fmt.Errorf("invalid or overlapping edit at offset %d", edit.Start)
+		}
+		out = append(out, src[last:edit.Start]...)
+		out = append(out, edit.NewText...)
+		last = edit.End
+	}
+	out = append(out, src[last:]...)
+
+	return format.Source(out)
+}
+
+// NewPackage type-checks files as a single package.
+func NewPackage(fset *token.FileSet, files []*ast.File, imp types.Importer) (*Package, error) {
+	var p *Package
+	p = &Package{
+		Fset:       fset,
+		Files:      files,
+		importer:   imp,
+		constTypes: make(map[*ast.ValueSpec]ast.Expr),
+		hoisted:    make(map[*types.Scope]map[string]bool),
+	}
+
+	var err error
+	p.Types, p.Info, err = p.check()
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// check type-checks the files of the package as they currently are.
+func (p *Package) check() (*types.Package, *types.Info, error) {
+	var info *types.Info
+	info = &types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Implicits:  make(map[ast.Node]types.Object),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+		Scopes:     make(map[ast.Node]*types.Scope),
+	}
+
+	var conf types.Config
+	conf = types.Config{
+		Importer:    p.importer,
+		FakeImportC: true,
+	}
+
+	var pkg *types.Package
+	var err error
+	pkg, err = conf.Check(p.Files[0].Name.Name, p.Fset, p.Files, info)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pkg, info, nil
+}
+
+// refuse records that the issue at pos was left unfixed.
+func (p *Package) refuse(pos token.Pos, rule string, reason string) {
+	p.Refusals = append(p.Refusals, Refusal{
+		Pos:    p.Fset.Position(pos),
+		Rule:   rule,
+		Reason: reason,
+	})
+}
+
+// Load parses and type-checks the packages containing filenames. Every
+// file of a package is loaded so that the type information is complete,
+// including the test files. Packages that fail to type-check are left
+// out and reported in the returned error.
+func Load(filenames []string) ([]*Package, error) {
+	return LoadContext(&build.Default, filenames)
+}
+
+// LoadContext is Load with the files of the packages selected by the
+// build context ctxt.
+func LoadContext(ctxt *build.Context, filenames []string) ([]*Package, error) {
+	var fset *token.FileSet
+	fset = token.NewFileSet()
+
+	// The imported packages are those the typed rules see, selected by
+	// ctxt and read through its overlay.
+	var imp types.Importer
+	imp = loader.NewImporter(ctxt)
+
+	var dirs []string
+	var seen map[string]bool
+	seen = make(map[string]bool)
+	var filename string
+	for _, filename = range filenames {
+		var dir string
+		var err error
+		dir, err = filepath.Abs(filepath.Dir(filename))
+		if err != nil {
+			return nil, err
+		}
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+
+	var pkgs []*Package
+	var errs []error
+
+	var dir string
+	for _, dir = range dirs {
+		var bp *build.Package
+		var err error
+		bp, err = ctxt.ImportDir(dir, 0)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", dir, err))
+			continue
+		}
+
+		var groups [][]string
+		groups = [][]string{
+			append(append(append([]string{}, bp.GoFiles...), bp.CgoFiles...), bp.TestGoFiles...),
+			bp.XTestGoFiles,
+		}
+
+		var names []string
+		for _, names = range groups {
+			if len(names) == 0 {
+				continue
+			}
+
+			var files []*ast.File
+			var name string
+			for _, name = range names {
+				var src []byte
+				src, err = loader.ReadFile(ctxt, filepath.Join(dir, name))
+				if err != nil {
+					break
+				}
+				var file *ast.File
+				file, err = parser.ParseFile(fset, filepath.Join(dir, name), src, parser.ParseComments)
+				if err != nil {
+					break
+				}
+				files = append(files, file)
+			}
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			var pkg *Package
+			pkg, err = NewPackage(fset, files, imp)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			pkgs = append(pkgs, pkg)
+		}
+	}
+
+	return pkgs, errors.Join(errs...)
+}
+
+// Filename returns the name of the file as it was parsed.
+func (p *Package) Filename(file *ast.File) string {
+	return p.Fset.Position(file.Package).Filename
+}
+
+// offset returns the byte offset of pos in its file.
+func (p *Package) offset(pos token.Pos) int {
+	return p.Fset.Position(pos).Offset
+}
+
+// suggest appends to suggestions the fix made of edits, unless there are
+// none.
+func suggest(suggestions []Suggestion, rule string, description string, edits []syntax.TextEdit, positions ...token.Pos) []Suggestion {
+	if len(edits) == 0 {
+		return suggestions
+	}
+	return append(suggestions, Suggestion{
+		Rule:      rule,
+		Positions: positions,
+		Fix:       syntax.SuggestedFix{Description: description, Edits: edits},
+	})
+}
+
+// flagged returns the positions of file reported by issues.
+func flagged(p *Package, file *ast.File, issues []syntax.Issue) map[token.Pos]bool {
+	var tokenFile *token.File
+	tokenFile = p.Fset.File(file.Pos())
+
+	var positions map[token.Pos]bool
+	positions = make(map[token.Pos]bool)
+	var issue syntax.Issue
+	for _, issue = range issues {
+		positions[tokenFile.LineStart(issue.Line)+token.Pos(issue.Column-1)] = true
+	}
+	return positions
+}
@@ -0,0 +1,1256 @@
+package fix
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	syntax "github.com/thierry-f-78/go-syntax/pkg/types"
+)
+
+// runFixer type-checks code and returns it rewritten by fixer.
+func runFixer(t *testing.T, fixer Fixer, code string) string {
+	t.Helper()
+
+	var fset *token.FileSet
+	fset = token.NewFileSet()
+	var file *ast.File
+	var err error
+	file, err = parser.ParseFile(fset, "test.go", code, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("Failed to parse code: %v", err)
+	}
+
+	var pkg *Package
+	pkg, err = NewPackage(fset, []*ast.File{file}, importer.ForCompiler(fset, "source", nil))
+	if err != nil {
+		t.Fatalf("Failed to type-check code: %v", err)
+	}
+
+	var edits []syntax.TextEdit
+	var suggestion Suggestion
+	for _, suggestion = range fixer(pkg, file) {
+		edits = append(edits, suggestion.Fix.Edits...)
+	}
+
+	var out []byte
+	out, err = Apply([]byte(code), edits)
+	if err != nil {
+		t.Fatalf("Failed to apply edits: %v", err)
+	}
+	return string(out)
+}
+
+func TestApplyRejectsOverlappingEdits(t *testing.T) {
+	var err error
+	_, err = Apply([]byte("package main\n"), []syntax.TextEdit{
+		{Start: 0, End: 7, NewText: "package"},
+		{Start: 3, End: 5, NewText: "x"},
+	})
+	if err == nil {
+		t.Errorf("Expected an error for overlapping edits")
+	}
+}
+
+func TestShortVarDecl(t *testing.T) {
+	var tests []struct {
+		name     string
+		code     string
+		expected string
+	}
+	tests = []struct {
+		name     string
+		code     string
+		expected string
+	}{
+		{
+			name: "single assignment keeps comment",
+			code: `package main
+func main() {
+	x := 42 // the answer
+	_ = x
+}
+`,
+			expected: `package main
+
+func main() {
+	var x int = 42 // the answer
+	_ = x
+}
+`,
+		},
+		{
+			name: "imported type uses file import name",
+			code: `package main
+import str "strings"
+func main() {
+	r := str.NewReader("a")
+	_ = r
+}
+`,
+			expected: `package main
+
+import str "strings"
+
+func main() {
+	var r *str.Reader = str.NewReader("a")
+	_ = r
+}
+`,
+		},
+		{
+			name: "multi assign with same type",
+			code: `package main
+func main() {
+	a, b := 1, 2
+	_, _ = a, b
+}
+`,
+			expected: `package main
+
+func main() {
+	var a, b int = 1, 2
+	_, _ = a, b
+}
+`,
+		},
+		{
+			name: "multi assign with different types",
+			code: `package main
+func main() {
+	a, b := 1, "s"
+	_, _ = a, b
+}
+`,
+			expected: `package main
+
+func main() {
+	var a int
+	var b string
+	a, b = 1, "s"
+	_, _ = a, b
+}
+`,
+		},
+		{
+			name: "redeclaration only declares new names",
+			code: `package main
+func f() (int, error) { return 0, nil }
+func main() {
+	var err error
+	n, err := f()
+	_, _ = n, err
+}
+`,
+			expected: `package main
+
+func f() (int, error) { return 0, nil }
+func main() {
+	var err error
+	var n int
+	n, err = f()
+	_, _ = n, err
+}
+`,
+		},
+		{
+			name: "range variables are hoisted",
+			code: `package main
+func main() {
+	for i, s := range []string{"a"} {
+		_, _ = i, s
+	}
+}
+`,
+			expected: `package main
+
+func main() {
+	var i int
+	var s string
+	for i, s = range []string{"a"} {
+		_, _ = i, s
+	}
+}
+`,
+		},
+		{
+			name: "range variable captured by closure is left as is",
+			code: `package main
+func main() {
+	for _, s := range []string{"a"} {
+		go func() { _ = s }()
+	}
+}
+`,
+			expected: `package main
+
+func main() {
+	for _, s := range []string{"a"} {
+		go func() { _ = s }()
+	}
+}
+`,
+		},
+		{
+			name: "second range with same name is left as is",
+			code: `package main
+func main() {
+	for i := range []int{1} {
+		_ = i
+	}
+	for i := range []int{1} {
+		_ = i
+	}
+}
+`,
+			expected: `package main
+
+func main() {
+	var i int
+	for i = range []int{1} {
+		_ = i
+	}
+	for i := range []int{1} {
+		_ = i
+	}
+}
+`,
+		},
+		{
+			name: "shadowing redeclaration is left as is",
+			code: `package main
+func main() {
+	x := 1
+	{
+		x, y := x+1, "s"
+		_, _ = x, y
+	}
+	_ = x
+}
+`,
+			expected: `package main
+
+func main() {
+	var x int = 1
+	{
+		x, y := x+1, "s"
+		_, _ = x, y
+	}
+	_ = x
+}
+`,
+		},
+		{
+			name: "alias type keeps its name",
+			code: `package main
+import "os"
+func main() {
+	info, err := os.Stat(".")
+	_, _ = info, err
+}
+`,
+			expected: `package main
+
+import "os"
+
+func main() {
+	var info os.FileInfo
+	var err error
+	info, err = os.Stat(".")
+	_, _ = info, err
+}
+`,
+		},
+		{
+			name: "type from a package the file does not import adds the import",
+			code: `package main
+import "os"
+func main() {
+	mode := os.ModePerm
+	_ = mode
+}
+`,
+			expected: `package main
+
+import "os"
+import "io/fs"
+
+func main() {
+	var mode fs.FileMode = os.ModePerm
+	_ = mode
+}
+`,
+		},
+		{
+			name: "import shadowed by a local variable is left as is",
+			code: `package main
+import "os"
+func main() {
+	fs := 1
+	mode := os.ModePerm
+	_, _ = fs, mode
+}
+`,
+			expected: `package main
+
+import "os"
+
+func main() {
+	var fs int = 1
+	mode := os.ModePerm
+	_, _ = fs, mode
+}
+`,
+		},
+		{
+			name: "type shadowed by a local variable is left as is",
+			code: `package main
+type user struct{}
+func getUser() user { return user{} }
+func main() {
+	var user user
+	user = getUser()
+	u := getUser()
+	_, _ = user, u
+}
+`,
+			expected: `package main
+
+type user struct{}
+
+func getUser() user { return user{} }
+func main() {
+	var user user
+	user = getUser()
+	u := getUser()
+	_, _ = user, u
+}
+`,
+		},
+		{
+			name: "predeclared type shadowed by a local type is left as is",
+			code: `package main
+func main() {
+	type int = string
+	n := len("a")
+	var s int = "a"
+	_, _ = n, s
+}
+`,
+			expected: `package main
+
+func main() {
+	type int = string
+	n := len("a")
+	var s int = "a"
+	_, _ = n, s
+}
+`,
+		},
+		{
+			name: "if init is left as is",
+			code: `package main
+func main() {
+	if x := 1; x > 0 {
+	}
+}
+`,
+			expected: `package main
+
+func main() {
+	if x := 1; x > 0 {
+	}
+}
+`,
+		},
+	}
+
+	var tt struct {
+		name     string
+		code     string
+		expected string
+	}
+	for _, tt = range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			got = runFixer(t, ShortVarDecl, tt.code)
+			if got != tt.expected {
+				t.Errorf("Unexpected result:\n%s\nExpected:\n%s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestVarNoType(t *testing.T) {
+	var tests []struct {
+		name     string
+		code     string
+		expected string
+	}
+	tests = []struct {
+		name     string
+		code     string
+		expected string
+	}{
+		{
+			name: "function call",
+			code: `package main
+import "strings"
+func main() {
+	var r = strings.Split("a,b", ",") // parts
+	_ = r
+}
+`,
+			expected: `package main
+
+import "strings"
+
+func main() {
+	var r []string = strings.Split("a,b", ",") // parts
+	_ = r
+}
+`,
+		},
+		{
+			name: "grouped declaration",
+			code: `package main
+var (
+	a = 33
+	b int = 42
+	c, d = 1, "s"
+)
+`,
+			expected: `package main
+
+var (
+	a int    = 33
+	b int    = 42
+	c int    = 1
+	d string = "s"
+)
+`,
+		},
+		{
+			name: "multi name with same type",
+			code: `package main
+var a, b = 1, 2
+`,
+			expected: `package main
+
+var a, b int = 1, 2
+`,
+		},
+		{
+			name: "multi name with different types",
+			code: `package main
+var a, b = 1, 2.5
+`,
+			expected: `package main
+
+var a int = 1
+var b float64 = 2.5
+`,
+		},
+		{
+			name: "multi value call in function",
+			code: `package main
+import "strconv"
+func main() {
+	var n, err = strconv.Atoi("1")
+	_, _ = n, err
+}
+`,
+			expected: `package main
+
+import "strconv"
+
+func main() {
+	var n int
+	var err error
+	n, err = strconv.Atoi("1")
+	_, _ = n, err
+}
+`,
+		},
+		{
+			name: "multi value call at package level is left as is",
+			code: `package main
+import "strconv"
+var n, err = strconv.Atoi("1")
+`,
+			expected: `package main
+
+import "strconv"
+
+var n, err = strconv.Atoi("1")
+`,
+		},
+		{
+			name: "multi value call reading a shadowed variable is left as is",
+			code: `package main
+func h(n int) (int, string) { return n, "" }
+func main() {
+	var s string = "x"
+	{
+		var n, s = h(len(s))
+		_, _ = n, s
+	}
+	_ = s
+}
+`,
+			expected: `package main
+
+func h(n int) (int, string) { return n, "" }
+func main() {
+	var s string = "x"
+	{
+		var n, s = h(len(s))
+		_, _ = n, s
+	}
+	_ = s
+}
+`,
+		},
+		{
+			name: "split reading a shadowed variable is left as is",
+			code: `package main
+func main() {
+	var a int = 1
+	{
+		var a, b = 2.5, a
+		_, _ = a, b
+	}
+	_ = a
+}
+`,
+			expected: `package main
+
+func main() {
+	var a int = 1
+	{
+		var a, b = 2.5, a
+		_, _ = a, b
+	}
+	_ = a
+}
+`,
+		},
+		{
+			name: "type shadowed by a local variable is left as is",
+			code: `package main
+type user struct{}
+func getUser() user { return user{} }
+func main() {
+	var user user
+	user = getUser()
+	var u = getUser()
+	_, _ = user, u
+}
+`,
+			expected: `package main
+
+type user struct{}
+
+func getUser() user { return user{} }
+func main() {
+	var user user
+	user = getUser()
+	var u = getUser()
+	_, _ = user, u
+}
+`,
+		},
+		{
+			name: "missing import is added",
+			code: `package main
+import "os"
+var mode = os.ModePerm | 0
+`,
+			expected: `package main
+
+import "os"
+import "io/fs"
+
+var mode fs.FileMode = os.ModePerm | 0
+`,
+		},
+		{
+			name: "unflagged declarations are left as is",
+			code: `package main
+var s = "hello"
+var x = []int{1}
+`,
+			expected: `package main
+
+var s = "hello"
+var x = []int{1}
+`,
+		},
+	}
+
+	var tt struct {
+		name     string
+		code     string
+		expected string
+	}
+	for _, tt = range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			got = runFixer(t, VarNoType, tt.code)
+			if got != tt.expected {
+				t.Errorf("Unexpected result:\n%s\nExpected:\n%s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	var before string = "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	var after string = "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+	var expected string = `--- a/x.go
++++ b/x.go
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -8,3 +8,4 @@
+ h
+ i
+ j
++k
+`
+	var got string
+	got = Diff("x.go", []byte(before), []byte(after))
+	if got != expected {
+		t.Errorf("Unexpected diff:\n%s\nExpected:\n%s", got, expected)
+	}
+
+	if Diff("x.go", []byte(before), []byte(before)) != "" {
+		t.Errorf("Expected no diff for identical content")
+	}
+}
+
+func TestConstNoType(t *testing.T) {
+	var tests []struct {
+		name     string
+		code     string
+		expected string
+		refused  int
+	}
+	tests = []struct {
+		name     string
+		code     string
+		expected string
+		refused  int
+	}{
+		{
+			name: "default type",
+			code: `package main
+const BufferSize = 1024
+const Pi = 3.14
+const Letter = 'a'
+`,
+			expected: `package main
+
+const BufferSize int = 1024
+const Pi float64 = 3.14
+const Letter rune = 'a'
+`,
+		},
+		{
+			name: "single named type from conversions",
+			code: `package main
+import "time"
+const Timeout = 5
+var d = time.Duration(Timeout) * time.Second
+var e time.Duration = Timeout
+`,
+			expected: `package main
+
+import "time"
+
+const Timeout time.Duration = 5
+
+var d = time.Duration(Timeout) * time.Second
+var e time.Duration = Timeout
+`,
+		},
+		{
+			name: "iota group is typed through its first spec",
+			code: `package main
+type Color int
+const (
+	Red = iota
+	Green
+)
+var c Color = Green
+`,
+			expected: `package main
+
+type Color int
+
+const (
+	Red Color = iota
+	Green
+)
+
+var c Color = Green
+`,
+		},
+		{
+			name: "exported constant of a library is refused",
+			code: `package lib
+const Max = 10
+const min = 1
+`,
+			expected: `package lib
+
+const Max = 10
+const min int = 1
+`,
+			refused: 1,
+		},
+		{
+			name: "incompatible uses are refused",
+			code: `package main
+const Size = 8
+var a int = Size
+var b float64 = Size
+`,
+			expected: `package main
+
+const Size = 8
+
+var a int = Size
+var b float64 = Size
+`,
+			refused: 1,
+		},
+		{
+			name: "value change is refused",
+			code: `package main
+const Ratio = 3
+const Half = Ratio / 2.0
+var h float64 = Half
+`,
+			expected: `package main
+
+const Ratio = 3
+const Half float64 = Ratio / 2.0
+
+var h float64 = Half
+`,
+			refused: 1,
+		},
+	}
+
+	var tt struct {
+		name     string
+		code     string
+		expected string
+		refused  int
+	}
+	for _, tt = range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var refused int
+			var got string
+			got = runFixer(t, func(p *Package, file *ast.File) []Suggestion {
+				var suggestions []Suggestion
+				suggestions = ConstNoType(p, file)
+				refused = len(p.Refusals)
+				return suggestions
+			}, tt.code)
+			if got != tt.expected {
+				t.Errorf("Unexpected result:\n%s\nExpected:\n%s", got, tt.expected)
+			}
+			if refused != tt.refused {
+				t.Errorf("Expected %d refusals, got %d", tt.refused, refused)
+			}
+		})
+	}
+}
+
+func TestNamedReturns(t *testing.T) {
+	var tests []struct {
+		name     string
+		code     string
+		expected string
+		refused  int
+	}
+	tests = []struct {
+		name     string
+		code     string
+		expected string
+		refused  int
+	}{
+		{
+			name: "naked returns are spelled out",
+			code: `package main
+import "errors"
+func divide(a, b int) (result int, err error) {
+	if b == 0 {
+		err = errors.New("division by zero")
+		return
+	}
+	result = a / b
+	return
+}
+`,
+			expected: `package main
+
+import "errors"
+
+func divide(a, b int) (int, error) {
+	var result int
+	var err error
+	if b == 0 {
+		err = errors.New("division by zero")
+		return result, err
+	}
+	result = a / b
+	return result, err
+}
+`,
+		},
+		{
+			name: "grouped names and single result",
+			code: `package main
+func pair() (x, y int) {
+	return 1, 2
+}
+func one() (n int) {
+	n = 1
+	return
+}
+`,
+			expected: `package main
+
+func pair() (int, int) {
+	var x int
+	var y int
+	return 1, 2
+}
+func one() int {
+	var n int
+	n = 1
+	return n
+}
+`,
+		},
+		{
+			name: "comment after the brace stays on its line",
+			code: `package main
+func one() (n int) { // one
+	n = 1
+	return
+}
+`,
+			expected: `package main
+
+func one() int { // one
+	var n int
+	n = 1
+	return n
+}
+`,
+		},
+		{
+			name: "returns of function literals are kept",
+			code: `package main
+func f() (n int) {
+	var g func()
+	g = func() { return }
+	g()
+	return
+}
+`,
+			expected: `package main
+
+func f() int {
+	var n int
+	var g func()
+	g = func() { return }
+	g()
+	return n
+}
+`,
+		},
+		{
+			name: "deferred closure modifying a result is refused",
+			code: `package main
+import "fmt"
+func safe() (err error) {
+	defer func() {
+		var r interface{}
+		r = recover()
+		if r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return
+}
+`,
+			expected: `package main
+
+import "fmt"
+
+func safe() (err error) {
+	defer func() {
+		var r interface{}
+		r = recover()
+		if r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return
+}
+`,
+			refused: 1,
+		},
+		{
+			name: "deferred recover is refused",
+			code: `package main
+func guard() { recover() }
+func f() (n int) {
+	defer guard()
+	n = 5
+	panic("x")
+}
+`,
+			expected: `package main
+
+func guard() { recover() }
+func f() (n int) {
+	defer guard()
+	n = 5
+	panic("x")
+}
+`,
+			refused: 1,
+		},
+		{
+			name: "deferred function variable modifying a result is refused",
+			code: `package main
+import "fmt"
+func f() (err error) {
+	var wrap func()
+	wrap = func() { err = fmt.Errorf("x: %w", err) }
+	defer wrap()
+	return
+}
+`,
+			expected: `package main
+
+import "fmt"
+
+func f() (err error) {
+	var wrap func()
+	wrap = func() { err = fmt.Errorf("x: %w", err) }
+	defer wrap()
+	return
+}
+`,
+			refused: 1,
+		},
+		{
+			name: "deferred recover through a function variable is refused",
+			code: `package main
+var guard func() = func() { recover() }
+func f() (n int) {
+	defer guard()
+	n = 5
+	panic("x")
+}
+`,
+			expected: `package main
+
+var guard func() = func() { recover() }
+
+func f() (n int) {
+	defer guard()
+	n = 5
+	panic("x")
+}
+`,
+			refused: 1,
+		},
+		{
+			name: "deferred pointer method on a result is refused",
+			code: `package main
+type conn struct{ closed bool }
+func (c *conn) Close() { c.closed = true }
+func open() (c conn) {
+	defer c.Close()
+	return
+}
+`,
+			expected: `package main
+
+type conn struct{ closed bool }
+
+func (c *conn) Close() { c.closed = true }
+func open() (c conn) {
+	defer c.Close()
+	return
+}
+`,
+			refused: 1,
+		},
+		{
+			name: "deferred value method on a result is accepted",
+			code: `package main
+type conn struct{ closed bool }
+func (c conn) Log() {}
+func open() (c conn) {
+	defer c.Log()
+	return
+}
+`,
+			expected: `package main
+
+type conn struct{ closed bool }
+
+func (c conn) Log() {}
+func open() conn {
+	var c conn
+	defer c.Log()
+	return c
+}
+`,
+		},
+		{
+			name: "result passed by address to a deferred call is refused",
+			code: `package main
+func capture(err *error) {}
+func f() (err error) {
+	defer capture(&err)
+	return
+}
+`,
+			expected: `package main
+
+func capture(err *error) {}
+func f() (err error) {
+	defer capture(&err)
+	return
+}
+`,
+			refused: 1,
+		},
+	}
+
+	var tt struct {
+		name     string
+		code     string
+		expected string
+		refused  int
+	}
+	for _, tt = range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var refused int
+			var got string
+			got = runFixer(t, func(p *Package, file *ast.File) []Suggestion {
+				var suggestions []Suggestion
+				suggestions = NamedReturns(p, file)
+				refused = len(p.Refusals)
+				return suggestions
+			}, tt.code)
+			if got != tt.expected {
+				t.Errorf("Unexpected result:\n%s\nExpected:\n%s", got, tt.expected)
+			}
+			if refused != tt.refused {
+				t.Errorf("Expected %d refusals, got %d", tt.refused, refused)
+			}
+		})
+	}
+}
+
+func TestIfInit(t *testing.T) {
+	var tests []struct {
+		name     string
+		code     string
+		expected string
+	}
+	tests = []struct {
+		name     string
+		code     string
+		expected string
+	}{
+		{
+			name: "declaration is hoisted",
+			code: `package main
+import "errors"
+func f() error { return errors.New("x") }
+func main() {
+	if err := f(); err != nil { // failed
+		panic(err)
+	}
+}
+`,
+			expected: `package main
+
+import "errors"
+
+func f() error { return errors.New("x") }
+func main() {
+	var err error = f()
+	if err != nil { // failed
+		panic(err)
+	}
+}
+`,
+		},
+		{
+			name: "assignment is hoisted",
+			code: `package main
+func main() {
+	var x int
+	if x = 1; x > 0 {
+	}
+}
+`,
+			expected: `package main
+
+func main() {
+	var x int
+	x = 1
+	if x > 0 {
+	}
+}
+`,
+		},
+		{
+			name: "collision wraps the result in a block",
+			code: `package main
+func f() (int, bool) { return 0, false }
+func main() {
+	var ok bool
+	if v, ok := f(); ok {
+		_ = v
+	}
+	_ = ok
+}
+`,
+			expected: `package main
+
+func f() (int, bool) { return 0, false }
+func main() {
+	var ok bool
+	{
+		var v int
+		var ok bool
+		v, ok = f()
+		if ok {
+			_ = v
+		}
+	}
+	_ = ok
+}
+`,
+		},
+		{
+			name: "else if init is wrapped in the else block",
+			code: `package main
+func f() int { return 0 }
+func main() {
+	var a bool
+	if a {
+	} else if n := f(); n > 0 {
+		_ = n
+	} else {
+	}
+}
+`,
+			expected: `package main
+
+func f() int { return 0 }
+func main() {
+	var a bool
+	if a {
+	} else {
+		var n int = f()
+		if n > 0 {
+			_ = n
+		} else {
+		}
+	}
+}
+`,
+		},
+		{
+			name: "chain of inits",
+			code: `package main
+func f() int { return 0 }
+func main() {
+	if a := f(); a > 0 {
+	} else if b := f(); b > 0 {
+	} else if c := f(); c > 0 {
+	}
+}
+`,
+			expected: `package main
+
+func f() int { return 0 }
+func main() {
+	var a int = f()
+	if a > 0 {
+	} else {
+		var b int = f()
+		if b > 0 {
+		} else {
+			var c int = f()
+			if c > 0 {
+			}
+		}
+	}
+}
+`,
+		},
+		{
+			name: "second if with same name is wrapped",
+			code: `package main
+func f() int { return 0 }
+func main() {
+	if n := f(); n > 0 {
+	}
+	if n := f(); n > 1 {
+	}
+}
+`,
+			expected: `package main
+
+func f() int { return 0 }
+func main() {
+	var n int = f()
+	if n > 0 {
+	}
+	{
+		var n int = f()
+		if n > 1 {
+		}
+	}
+}
+`,
+		},
+	}
+
+	var tt struct {
+		name     string
+		code     string
+		expected string
+	}
+	for _, tt = range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			got = runFixer(t, IfInit, tt.code)
+			if got != tt.expected {
+				t.Errorf("Unexpected result:\n%s\nExpected:\n%s", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package fix
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"github.com/thierry-f-78/go-syntax/pkg/rules"
+	syntax "github.com/thierry-f-78/go-syntax/pkg/types"
+)
+
+// IfInit moves the init statement of the if statements of file flagged by
+// rules.IfInitRule before the if, declarations becoming explicitly typed:
+//
+//	if err := f(); err != nil {  ->  var err error = f()
+//	                                 if err != nil {
+//
+// The variables declared by the init statement are scoped to the if. When
+// they would collide with, or hide, a name of the enclosing block, and for
+// labeled ifs and 'else if', the result is wrapped in a new block instead:
+//
+//	} else if v, ok := m[k]; ok {  ->  } else {
+//	                                       var v T
+//	                                       var ok bool
+//	                                       v, ok = m[k]
+//	                                       if ok {
+//	                                       ...
+//	                                   }
+func IfInit(p *Package, file *ast.File) []Suggestion {
+	var reported map[token.Pos]bool
+	reported = flagged(p, file, (&rules.IfInitRule{}).Check(p.Fset, file))
+
+	var suggestions []Suggestion
+	var q *qualifier
+	q = newQualifier(p, file)
+
+	var hasGoto bool
+	hasGoto = containsGoto(file)
+
+	// fixChain fixes the flagged ifs of the if/else chain starting at head
+	// as a single fix: the blocks wrapping its 'else if' all end where the
+	// chain ends.
+	var fixChain func(head *ast.IfStmt, wrap bool, following []ast.Stmt)
+	fixChain = func(head *ast.IfStmt, wrap bool, following []ast.Stmt) {
+		var edits []syntax.TextEdit
+		var positions []token.Pos
+		var closers int
+
+		var ifStmt *ast.IfStmt = head
+		for ifStmt != nil {
+			if ifStmt.Init != nil && reported[ifStmt.Pos()] {
+				q.begin()
+				var stmtEdits []syntax.TextEdit
+				var wrapped bool
+				stmtEdits, wrapped = ifInitStmt(p, q, ifStmt, wrap, following)
+				if stmtEdits != nil {
+					edits = append(append(edits, stmtEdits...), q.commit()...)
+					positions = append(positions, ifStmt.Pos())
+					if wrapped {
+						closers++
+					}
+				}
+			}
+			ifStmt, _ = ifStmt.Else.(*ast.IfStmt)
+			wrap = true
+			following = nil
+		}
+
+		if closers > 0 {
+			edits = append(edits, syntax.TextEdit{Start: p.offset(head.End()), End: p.offset(head.End()), NewText: strings.Repeat("\n}", closers)})
+		}
+		suggestions = suggest(suggestions, "if-init", "Move the init statement before the if", edits, positions...)
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		var list []ast.Stmt
+		switch node := n.(type) {
+		case *ast.BlockStmt:
+			list = node.List
+		case *ast.CaseClause:
+			list = node.Body
+		case *ast.CommClause:
+			list = node.Body
+		default:
+			return true
+		}
+
+		var i int
+		var stmt ast.Stmt
+		for i, stmt = range list {
+			switch s := stmt.(type) {
+			case *ast.IfStmt:
+				fixChain(s, hasGoto, list[i+1:])
+			case *ast.LabeledStmt:
+				// A goto to the label must still run the init statement.
+				var ifStmt *ast.IfStmt
+				var ok bool
+				ifStmt, ok = s.Stmt.(*ast.IfStmt)
+				if ok {
+					fixChain(ifStmt, true, nil)
+				}
+			}
+		}
+		return true
+	})
+
+	return suggestions
+}
+
+// ifInitStmt moves the init statement of ifStmt before it, in a new block
+// when wrap is set or when the names it declares cannot be hoisted into
+// the enclosing block. following are the statements after ifStmt in that
+// block. It reports whether the new block was opened; the caller closes
+// it and commits the imports the edits need.
+func ifInitStmt(p *Package, q *qualifier, ifStmt *ast.IfStmt, wrap bool, following []ast.Stmt) ([]syntax.TextEdit, bool) {
+	var edits []syntax.TextEdit
+
+	var names []string
+	var assign *ast.AssignStmt
+	var ok bool
+	assign, ok = ifStmt.Init.(*ast.AssignStmt)
+	if ok && assign.Tok == token.DEFINE {
+		edits = shortVarDeclAssign(p, q, assign)
+		if edits == nil {
+			p.refuse(ifStmt.Pos(), "if-init", "the types of the variables it declares cannot be written here")
+			return nil, false
+		}
+
+		var lhs ast.Expr
+		for _, lhs = range assign.Lhs {
+			if lhs.(*ast.Ident).Name != "_" {
+				names = append(names, lhs.(*ast.Ident).Name)
+			}
+		}
+	}
+
+	var block *types.Scope
+	block = p.Info.Scopes[ifStmt].Parent()
+	var name string
+	for _, name = range names {
+		if !wrap && !hoistable(p, block, name, ifStmt.Pos(), following) {
+			wrap = true
+		}
+	}
+	if !wrap {
+		for _, name = range names {
+			p.markHoisted(block, name)
+		}
+	}
+
+	var open string
+	if wrap {
+		open = "{\n"
+	}
+
+	return append(edits,
+		// "if init; cond {"  ->  "init\nif cond {"
+		syntax.TextEdit{Start: p.offset(ifStmt.If), End: p.offset(ifStmt.Init.Pos()), NewText: open},
+		syntax.TextEdit{Start: p.offset(ifStmt.Init.End()), End: p.offset(ifStmt.Cond.Pos()), NewText: "\nif "},
+	), wrap
+}
@@ -0,0 +1,358 @@
+package fix
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"github.com/thierry-f-78/go-syntax/pkg/rules"
+	syntax "github.com/thierry-f-78/go-syntax/pkg/types"
+)
+
+// NamedReturns turns the named results of the functions of file flagged
+// by rules.NamedReturnsRule into explicitly typed locals, and spells out
+// their naked returns:
+//
+//	func divide(a, b int) (result int, err error) {  ->  func divide(a, b int) (int, error) {
+//		...                                                  var result int
+//		return                                               var err error
+//	}                                                        ...
+//	                                                         return result, err
+//	                                                     }
+//
+// The fix of a function is suggested for its named-returns issues and for
+// the naked-return issues of its body alike.
+//
+// Functions where a deferred call can observe the named results, or
+// recover from a panic, are left as is: once unnamed, the returned values
+// would no longer be visible to it.
+func NamedReturns(p *Package, file *ast.File) []Suggestion {
+	var reported map[token.Pos]bool
+	reported = flagged(p, file, (&rules.NamedReturnsRule{}).Check(p.Fset, file))
+
+	var suggestions []Suggestion
+	var decl ast.Decl
+	for _, decl = range file.Decls {
+		var funcDecl *ast.FuncDecl
+		var ok bool
+		funcDecl, ok = decl.(*ast.FuncDecl)
+		if !ok || funcDecl.Body == nil || funcDecl.Type.Results == nil {
+			continue
+		}
+
+		var fields []token.Pos
+		var field *ast.Field
+		for _, field = range funcDecl.Type.Results.List {
+			if reported[field.Pos()] {
+				fields = append(fields, field.Pos())
+			}
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		var edits []syntax.TextEdit
+		var returns []token.Pos
+		edits, returns = namedReturnsFunc(p, file, funcDecl)
+		if edits == nil {
+			continue
+		}
+		suggestions = suggest(suggestions, "named-returns", "Turn the named results into local variables", edits, fields...)
+		suggestions = suggest(suggestions, "naked-return", "Turn the named results into local variables", edits, returns...)
+	}
+
+	return suggestions
+}
+
+// namedReturnsFunc rewrites funcDecl, and returns the positions of the
+// naked returns it spells out along with the edits.
+func namedReturnsFunc(p *Package, file *ast.File, funcDecl *ast.FuncDecl) ([]syntax.TextEdit, []token.Pos) {
+	var results *ast.FieldList
+	results = funcDecl.Type.Results
+
+	var names []string
+	var objs []*types.Var
+	var decls strings.Builder
+	var edits []syntax.TextEdit
+	var returns []token.Pos
+
+	var field *ast.Field
+	for _, field = range results.List {
+		var typ string
+		typ = types.ExprString(field.Type)
+
+		var name *ast.Ident
+		for _, name = range field.Names {
+			if name.Name == "_" {
+				refuseNamedReturns(p, results, "blank result names cannot be turned into locals")
+				return nil, nil
+			}
+			var v *types.Var
+			var ok bool
+			v, ok = p.Info.Defs[name].(*types.Var)
+			if !ok {
+				return nil, nil
+			}
+			names = append(names, name.Name)
+			objs = append(objs, v)
+			decls.WriteString("\nvar " + name.Name + " " + typ)
+		}
+
+		// (a, b int)  ->  (int, int)
+		if len(field.Names) > 0 {
+			edits = append(edits, syntax.TextEdit{
+				Start:   p.offset(field.Pos()),
+				End:     p.offset(field.Type.Pos()),
+				NewText: strings.Repeat(typ+", ", len(field.Names)-1),
+			})
+		}
+	}
+
+	var reason string
+	reason = deferObserves(p, funcDecl.Body, objs)
+	if reason != "" {
+		refuseNamedReturns(p, results, reason)
+		return nil, nil
+	}
+
+	// (err error)  ->  error
+	if len(names) == 1 && results.Opening.IsValid() {
+		edits = append(edits,
+			syntax.TextEdit{Start: p.offset(results.Opening), End: p.offset(results.Opening) + 1},
+			syntax.TextEdit{Start: p.offset(results.Closing), End: p.offset(results.Closing) + 1},
+		)
+	}
+
+	// The declarations go after a comment following the brace.
+	var insert token.Pos = funcDecl.Body.Lbrace + 1
+	var group *ast.CommentGroup
+	for _, group = range file.Comments {
+		if group.Pos() >= insert && p.Fset.Position(group.Pos()).Line == p.Fset.Position(insert).Line {
+			insert = group.End()
+		}
+	}
+	edits = append(edits, syntax.TextEdit{
+		Start:   p.offset(insert),
+		End:     p.offset(insert),
+		NewText: decls.String(),
+	})
+
+	ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ReturnStmt:
+			if len(node.Results) == 0 {
+				returns = append(returns, node.Pos())
+				edits = append(edits, syntax.TextEdit{
+					Start:   p.offset(node.Pos()),
+					End:     p.offset(node.End()),
+					NewText: "return " + strings.Join(names, ", "),
+				})
+			}
+		}
+		return true
+	})
+
+	return edits, returns
+}
+
+// refuseNamedReturns records the reason why the named results of results,
+// each reported on its own, are left as is.
+func refuseNamedReturns(p *Package, results *ast.FieldList, reason string) {
+	var field *ast.Field
+	for _, field = range results.List {
+		p.refuse(field.Pos(), "named-returns", reason)
+	}
+}
+
+// deferObserves returns why a deferred call in body can observe vars
+// after the function returns, or "" when none can: a deferred function
+// literal using one of them, one of them passed by address or used as the
+// receiver of a pointer method, a deferred call recovering from a panic,
+// which returns the values of the results when it happened, or a deferred
+// call to a function it cannot read.
+func deferObserves(p *Package, body *ast.BlockStmt, vars []*types.Var) string {
+	var found string
+	ast.Inspect(body, func(n ast.Node) bool {
+		var deferStmt *ast.DeferStmt
+		var ok bool
+		deferStmt, ok = n.(*ast.DeferStmt)
+		if !ok {
+			return found == ""
+		}
+
+		var callee *ast.BlockStmt
+		var known bool
+		callee, known = deferredBody(p, deferStmt.Call)
+		if !known {
+			found = "a deferred call runs a function the fix cannot inspect"
+			return false
+		}
+		if callee != nil && recovers(p, callee) {
+			found = "a deferred call recovers from panics"
+			return false
+		}
+
+		var name string
+		name = pointerReceiver(p, deferStmt.Call, vars)
+		ast.Inspect(deferStmt.Call, func(n ast.Node) bool {
+			if name != "" {
+				return false
+			}
+			switch node := n.(type) {
+			case *ast.FuncLit:
+				name = mentionedVar(p, node.Body, vars)
+				return false
+			case *ast.UnaryExpr:
+				if node.Op == token.AND {
+					name = mentionedVar(p, node.X, vars)
+				}
+			}
+			return name == ""
+		})
+		if name != "" {
+			found = "a deferred call uses the named result " + name
+		}
+		return found == ""
+	})
+	return found
+}
+
+// deferredBody returns the body of the function the deferred call runs,
+// nil for a builtin, and reports whether that function is known: a
+// literal, a builtin or a function of the package. A function variable,
+// a function of another package or an interface method may be anything,
+// a closure assigning the results included.
+func deferredBody(p *Package, call *ast.CallExpr) (*ast.BlockStmt, bool) {
+	var obj types.Object
+	switch fun := ast.Unparen(call.Fun).(type) {
+	case *ast.FuncLit:
+		return fun.Body, true
+	case *ast.Ident:
+		obj = p.Info.Uses[fun]
+	case *ast.SelectorExpr:
+		obj = p.Info.Uses[fun.Sel]
+	}
+
+	var ok bool
+	_, ok = obj.(*types.Builtin)
+	if ok {
+		return nil, true
+	}
+	var body *ast.BlockStmt
+	body = p.funcBody(obj)
+	return body, body != nil
+}
+
+// recovers reports whether body, that of a deferred function, calls
+// recover.
+func recovers(p *Package, body *ast.BlockStmt) bool {
+	var found bool
+	ast.Inspect(body, func(n ast.Node) bool {
+		var call *ast.CallExpr
+		var ok bool
+		call, ok = n.(*ast.CallExpr)
+		if ok {
+			var ident *ast.Ident
+			ident, ok = ast.Unparen(call.Fun).(*ast.Ident)
+			if ok {
+				var builtin *types.Builtin
+				builtin, ok = p.Info.Uses[ident].(*types.Builtin)
+				found = ok && builtin.Name() == "recover"
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// funcBody returns the body of the function or method obj when declared
+// in the package, or nil.
+func (p *Package) funcBody(obj types.Object) *ast.BlockStmt {
+	if obj == nil {
+		return nil
+	}
+	var file *ast.File
+	for _, file = range p.Files {
+		var decl ast.Decl
+		for _, decl = range file.Decls {
+			var funcDecl *ast.FuncDecl
+			var ok bool
+			funcDecl, ok = decl.(*ast.FuncDecl)
+			if ok && p.Info.Defs[funcDecl.Name] == obj {
+				return funcDecl.Body
+			}
+		}
+	}
+	return nil
+}
+
+// pointerReceiver returns the name of the one of vars whose address the
+// deferred call takes implicitly, calling a pointer method on it or on
+// one of its fields or array elements.
+func pointerReceiver(p *Package, call *ast.CallExpr, vars []*types.Var) string {
+	var sel *ast.SelectorExpr
+	var ok bool
+	sel, ok = ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	var selection *types.Selection
+	selection = p.Info.Selections[sel]
+	if selection == nil || selection.Kind() != types.MethodVal {
+		return ""
+	}
+	var isPointer bool
+	_, isPointer = selection.Recv().Underlying().(*types.Pointer)
+	if isPointer {
+		return ""
+	}
+	var signature *types.Signature
+	signature, ok = selection.Obj().Type().(*types.Signature)
+	if !ok || signature.Recv() == nil {
+		return ""
+	}
+	_, isPointer = signature.Recv().Type().(*types.Pointer)
+	if !isPointer {
+		return ""
+	}
+
+	// The receiver is a variable, or a field or an element of one. Those
+	// reached through a pointer are not copied by a return, but are left
+	// as is too.
+	var x ast.Expr = sel.X
+	for {
+		switch node := ast.Unparen(x).(type) {
+		case *ast.SelectorExpr:
+			x = node.X
+			continue
+		case *ast.IndexExpr:
+			x = node.X
+			continue
+		case *ast.Ident:
+			return mentionedVar(p, node, vars)
+		}
+		return ""
+	}
+}
+
+// mentionedVar returns the name of the first of vars referenced in n.
+func mentionedVar(p *Package, n ast.Node, vars []*types.Var) string {
+	var found string
+	ast.Inspect(n, func(n ast.Node) bool {
+		var ident *ast.Ident
+		var ok bool
+		ident, ok = n.(*ast.Ident)
+		if ok {
+			var v *types.Var
+			v, ok = p.Info.Uses[ident].(*types.Var)
+			if ok && containsVar(vars, v) {
+				found = v.Name()
+			}
+		}
+		return found == ""
+	})
+	return found
+}
@@ -0,0 +1,395 @@
+package fix
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strconv"
+
+	syntax "github.com/thierry-f-78/go-syntax/pkg/types"
+)
+
+// qualifier renders types the way a file refers to them. Packages the
+// file does not import yet are imported under their own name, provided
+// that name is free where the type is written.
+//
+// The packages needed by a fix are only imported once the fix calls
+// commit, so that abandoned fixes do not leave unused imports behind.
+type qualifier struct {
+	p          *Package
+	file       *ast.File
+	pkg        *types.Package
+	names      map[string]string
+	pending    map[string]*types.Package
+	added      map[string]*types.Package
+	pos        token.Pos
+	incomplete bool
+}
+
+func newQualifier(p *Package, file *ast.File) *qualifier {
+	var q *qualifier
+	q = &qualifier{
+		p:       p,
+		file:    file,
+		pkg:     p.Types,
+		names:   make(map[string]string),
+		pending: make(map[string]*types.Package),
+		added:   make(map[string]*types.Package),
+	}
+
+	var spec *ast.ImportSpec
+	for _, spec = range file.Imports {
+		var obj types.Object
+		if spec.Name != nil {
+			obj = p.Info.Defs[spec.Name]
+		} else {
+			obj = p.Info.Implicits[spec]
+		}
+
+		var pkgName *types.PkgName
+		var ok bool
+		pkgName, ok = obj.(*types.PkgName)
+		if !ok {
+			continue
+		}
+		if pkgName.Name() == "_" {
+			continue
+		}
+		if pkgName.Name() == "." {
+			q.names[pkgName.Imported().Path()] = ""
+			continue
+		}
+		q.names[pkgName.Imported().Path()] = pkgName.Name()
+	}
+
+	return q
+}
+
+// begin starts a new fix, forgetting the imports of an abandoned one.
+func (q *qualifier) begin() {
+	q.pending = make(map[string]*types.Package)
+}
+
+// commit records the imports needed by the current fix, and returns the
+// edits adding them to the file.
+func (q *qualifier) commit() []syntax.TextEdit {
+	var paths []string
+	var path string
+	var pkg *types.Package
+	for path, pkg = range q.pending {
+		q.added[path] = pkg
+		paths = append(paths, path)
+	}
+	q.pending = make(map[string]*types.Package)
+
+	sort.Strings(paths)
+	return q.importEdits(paths)
+}
+
+func (q *qualifier) qualify(pkg *types.Package) string {
+	if pkg == q.pkg {
+		return ""
+	}
+
+	var name string
+	var ok bool
+	name, ok = q.names[pkg.Path()]
+	if ok {
+		if name != "" && !q.refersTo(name, pkg) {
+			q.incomplete = true
+		}
+		return name
+	}
+
+	name = pkg.Name()
+	if !q.refersTo(name, nil) || q.nameTaken(name, pkg.Path()) {
+		q.incomplete = true
+		return name
+	}
+	q.pending[pkg.Path()] = pkg
+	return name
+}
+
+// refersTo reports whether name, written at q.pos, denotes the import of
+// pkg, or nothing at all when pkg is nil.
+func (q *qualifier) refersTo(name string, pkg *types.Package) bool {
+	var obj types.Object
+	obj = q.lookup(name)
+	if pkg == nil {
+		return obj == nil
+	}
+
+	var pkgName *types.PkgName
+	var ok bool
+	pkgName, ok = obj.(*types.PkgName)
+	return ok && pkgName.Imported() == pkg
+}
+
+// nameTaken reports whether another import of the file, existing or to be
+// added, already uses name.
+func (q *qualifier) nameTaken(name string, path string) bool {
+	var other string
+	var used string
+	for other, used = range q.names {
+		if used == name && other != path {
+			return true
+		}
+	}
+	var pkg *types.Package
+	for _, pkg = range q.added {
+		if pkg.Name() == name && pkg.Path() != path {
+			return true
+		}
+	}
+	for _, pkg = range q.pending {
+		if pkg.Name() == name && pkg.Path() != path {
+			return true
+		}
+	}
+	return false
+}
+
+// typeString renders t as written at pos, or reports false when the type
+// cannot be written there.
+func (q *qualifier) typeString(t types.Type, pos token.Pos) (string, bool) {
+	if !expressible(t, q.pkg, make(map[types.Type]bool)) {
+		return "", false
+	}
+
+	var s string
+	q.pos = pos
+	q.incomplete = false
+	s = types.TypeString(t, q.qualify)
+	if q.incomplete || !q.visible(t, make(map[types.Type]bool)) {
+		return "", false
+	}
+	return s, true
+}
+
+// visible reports whether the names t is written with that carry no
+// package, those of the package itself, of its dot imports and of the
+// universe, denote the types of t at q.pos rather than a declaration
+// hiding them.
+func (q *qualifier) visible(t types.Type, seen map[types.Type]bool) bool {
+	if seen[t] {
+		return true
+	}
+	seen[t] = true
+
+	switch u := t.(type) {
+	case *types.Basic:
+		if u.Kind() == types.UnsafePointer {
+			return true
+		}
+		return q.lookup(u.Name()) == types.Universe.Lookup(u.Name())
+	case *types.Named:
+		if q.bare(u.Obj()) && q.lookup(u.Obj().Name()) != u.Obj() {
+			return false
+		}
+		var i int
+		for i = 0; i < u.TypeArgs().Len(); i++ {
+			if !q.visible(u.TypeArgs().At(i), seen) {
+				return false
+			}
+		}
+		return true
+	case *types.Alias:
+		return !q.bare(u.Obj()) || q.lookup(u.Obj().Name()) == u.Obj()
+	case *types.TypeParam:
+		return q.lookup(u.Obj().Name()) == u.Obj()
+	case *types.Pointer:
+		return q.visible(u.Elem(), seen)
+	case *types.Slice:
+		return q.visible(u.Elem(), seen)
+	case *types.Array:
+		return q.visible(u.Elem(), seen)
+	case *types.Chan:
+		return q.visible(u.Elem(), seen)
+	case *types.Map:
+		return q.visible(u.Key(), seen) && q.visible(u.Elem(), seen)
+	case *types.Signature:
+		return q.visible(u.Params(), seen) && q.visible(u.Results(), seen)
+	case *types.Tuple:
+		var i int
+		for i = 0; i < u.Len(); i++ {
+			if !q.visible(u.At(i).Type(), seen) {
+				return false
+			}
+		}
+		return true
+	case *types.Struct:
+		var i int
+		for i = 0; i < u.NumFields(); i++ {
+			if !q.visible(u.Field(i).Type(), seen) {
+				return false
+			}
+		}
+		return true
+	case *types.Interface:
+		var i int
+		for i = 0; i < u.NumExplicitMethods(); i++ {
+			if !q.visible(u.ExplicitMethod(i).Type(), seen) {
+				return false
+			}
+		}
+		for i = 0; i < u.NumEmbeddeds(); i++ {
+			if !q.visible(u.EmbeddedType(i), seen) {
+				return false
+			}
+		}
+		return true
+	}
+	return true
+}
+
+// bare reports whether the type name obj is written without a package
+// name.
+func (q *qualifier) bare(obj *types.TypeName) bool {
+	if obj.Pkg() == nil || obj.Pkg() == q.pkg {
+		return true
+	}
+	var name string
+	var ok bool
+	name, ok = q.names[obj.Pkg().Path()]
+	return ok && name == ""
+}
+
+// lookup returns the object name denotes at q.pos.
+func (q *qualifier) lookup(name string) types.Object {
+	var scope *types.Scope
+	scope = q.pkg.Scope().Innermost(q.pos)
+	if scope == nil {
+		scope = q.pkg.Scope()
+	}
+
+	var obj types.Object
+	_, obj = scope.LookupParent(name, q.pos)
+	return obj
+}
+
+// importEdits returns the edits adding the imports of paths to the file.
+// There is one edit per package so that fixes importing the same package
+// produce identical edits, which Apply merges.
+func (q *qualifier) importEdits(paths []string) []syntax.TextEdit {
+	var p *Package
+	var file *ast.File
+	p = q.p
+	file = q.file
+
+	// Add to the last import declaration, or after the package clause.
+	var last *ast.GenDecl
+	var decl ast.Decl
+	for _, decl = range file.Decls {
+		var gen *ast.GenDecl
+		var ok bool
+		gen, ok = decl.(*ast.GenDecl)
+		if ok && gen.Tok == token.IMPORT {
+			last = gen
+		}
+	}
+
+	var edits []syntax.TextEdit
+	var path string
+	for _, path = range paths {
+		var edit syntax.TextEdit
+		switch {
+		case last == nil:
+			edit = syntax.TextEdit{Start: p.offset(file.Name.End()), NewText: "\n\nimport " + strconv.Quote(path)}
+		case last.Rparen.IsValid():
+			edit = syntax.TextEdit{Start: p.offset(last.Rparen), NewText: strconv.Quote(path) + "\n"}
+		default:
+			edit = syntax.TextEdit{Start: p.offset(last.End()), NewText: "\nimport " + strconv.Quote(path)}
+		}
+		edit.End = edit.Start
+		edits = append(edits, edit)
+	}
+	return edits
+}
+
+// expressible reports whether t can be spelled out in package pkg.
+func expressible(t types.Type, pkg *types.Package, seen map[types.Type]bool) bool {
+	if seen[t] {
+		return true
+	}
+	seen[t] = true
+
+	switch u := t.(type) {
+	case *types.Basic:
+		return u.Info()&types.IsUntyped == 0
+	case *types.Named:
+		var obj *types.TypeName
+		obj = u.Obj()
+		if obj.Pkg() != nil && obj.Pkg() != pkg && !obj.Exported() {
+			return false
+		}
+		var i int
+		for i = 0; i < u.TypeArgs().Len(); i++ {
+			if !expressible(u.TypeArgs().At(i), pkg, seen) {
+				return false
+			}
+		}
+		return true
+	case *types.Alias:
+		var obj *types.TypeName
+		obj = u.Obj()
+		if obj.Pkg() != nil && obj.Pkg() != pkg && !obj.Exported() {
+			return false
+		}
+		return true
+	case *types.TypeParam:
+		return true
+	case *types.Pointer:
+		return expressible(u.Elem(), pkg, seen)
+	case *types.Slice:
+		return expressible(u.Elem(), pkg, seen)
+	case *types.Array:
+		return expressible(u.Elem(), pkg, seen)
+	case *types.Chan:
+		return expressible(u.Elem(), pkg, seen)
+	case *types.Map:
+		return expressible(u.Key(), pkg, seen) && expressible(u.Elem(), pkg, seen)
+	case *types.Signature:
+		return u.TypeParams() == nil && expressible(u.Params(), pkg, seen) && expressible(u.Results(), pkg, seen)
+	case *types.Tuple:
+		var i int
+		for i = 0; i < u.Len(); i++ {
+			if !expressible(u.At(i).Type(), pkg, seen) {
+				return false
+			}
+		}
+		return true
+	case *types.Struct:
+		var i int
+		for i = 0; i < u.NumFields(); i++ {
+			var field *types.Var
+			field = u.Field(i)
+			if !field.Exported() && field.Pkg() != pkg {
+				return false
+			}
+			if !expressible(field.Type(), pkg, seen) {
+				return false
+			}
+		}
+		return true
+	case *types.Interface:
+		var i int
+		for i = 0; i < u.NumExplicitMethods(); i++ {
+			var method *types.Func
+			method = u.ExplicitMethod(i)
+			if !method.Exported() && method.Pkg() != pkg {
+				return false
+			}
+			if !expressible(method.Type(), pkg, seen) {
+				return false
+			}
+		}
+		for i = 0; i < u.NumEmbeddeds(); i++ {
+			if !expressible(u.EmbeddedType(i), pkg, seen) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
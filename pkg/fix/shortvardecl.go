@@ -0,0 +1,385 @@
+package fix
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	syntax "github.com/thierry-f-78/go-syntax/pkg/types"
+)
+
+// ShortVarDecl rewrites the short variable declarations of file into
+// explicitly typed var declarations:
+//
+//	x := f()            ->  var x T = f()
+//	x, err := g()       ->  var x T          (err already declared)
+//	                        x, err = g()
+//	for i, v := range s ->  var i int
+//	                        var v E
+//	                        for i, v = range s
+//
+// Statements that cannot be rewritten without changing the meaning of the
+// program (if/for/switch init statements, range variables captured by
+// closures, names that would collide once hoisted...) are left as is.
+func ShortVarDecl(p *Package, file *ast.File) []Suggestion {
+	var suggestions []Suggestion
+	var q *qualifier
+	q = newQualifier(p, file)
+
+	var hasGoto bool
+	hasGoto = containsGoto(file)
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		var list []ast.Stmt
+		switch node := n.(type) {
+		case *ast.BlockStmt:
+			list = node.List
+		case *ast.CaseClause:
+			list = node.Body
+		case *ast.CommClause:
+			list = node.Body
+		default:
+			return true
+		}
+
+		var i int
+		var stmt ast.Stmt
+		for i, stmt = range list {
+			switch s := stmt.(type) {
+			case *ast.AssignStmt:
+				if s.Tok == token.DEFINE {
+					q.begin()
+					suggestions = suggestShortVarDecl(suggestions, q, s.Pos(), shortVarDeclAssign(p, q, s))
+				}
+			case *ast.RangeStmt:
+				if s.Tok == token.DEFINE && !hasGoto {
+					q.begin()
+					suggestions = suggestShortVarDecl(suggestions, q, s.Pos(), shortVarDeclRange(p, q, s, s, list[i+1:]))
+				}
+			case *ast.LabeledStmt:
+				var rangeStmt *ast.RangeStmt
+				var ok bool
+				rangeStmt, ok = s.Stmt.(*ast.RangeStmt)
+				if ok && rangeStmt.Tok == token.DEFINE && !hasGoto {
+					q.begin()
+					suggestions = suggestShortVarDecl(suggestions, q, rangeStmt.Pos(), shortVarDeclRange(p, q, s, rangeStmt, list[i+1:]))
+				}
+			}
+		}
+		return true
+	})
+
+	return suggestions
+}
+
+func suggestShortVarDecl(suggestions []Suggestion, q *qualifier, pos token.Pos, edits []syntax.TextEdit) []Suggestion {
+	if edits == nil {
+		return suggestions
+	}
+	return suggest(suggestions, "short-var-decl", "Declare the variables with an explicit type", append(edits, q.commit()...), pos)
+}
+
+// shortVarDeclAssign rewrites assign. The caller commits the imports the
+// edits need.
+func shortVarDeclAssign(p *Package, q *qualifier, assign *ast.AssignStmt) []syntax.TextEdit {
+	var newNames []string
+	var newTypes []string
+	var newObjs []types.Type
+	var allNew bool = true
+
+	var lhs ast.Expr
+	for _, lhs = range assign.Lhs {
+		var ident *ast.Ident
+		var ok bool
+		ident, ok = lhs.(*ast.Ident)
+		if !ok {
+			return nil
+		}
+
+		var obj types.Object
+		obj = p.Info.Defs[ident]
+		if obj == nil || ident.Name == "_" {
+			allNew = false
+			continue
+		}
+
+		var typ string
+		typ, ok = q.typeString(obj.Type(), assign.Pos())
+		if !ok {
+			return nil
+		}
+		newNames = append(newNames, ident.Name)
+		newTypes = append(newTypes, typ)
+		newObjs = append(newObjs, obj.Type())
+	}
+	if len(newNames) == 0 {
+		return nil
+	}
+
+	var sameType bool = true
+	var i int
+	for i = 1; i < len(newObjs); i++ {
+		if !types.Identical(newObjs[i], newObjs[0]) {
+			sameType = false
+		}
+	}
+
+	var tok syntax.TextEdit
+	tok = syntax.TextEdit{
+		Start:   p.offset(assign.TokPos),
+		End:     p.offset(assign.TokPos) + len(token.DEFINE.String()),
+		NewText: "=",
+	}
+
+	// x, y := a, b  ->  var x, y T = a, b
+	if allNew && sameType {
+		return []syntax.TextEdit{
+			{Start: p.offset(assign.Pos()), End: p.offset(assign.Pos()), NewText: "var "},
+			{Start: p.offset(assign.Lhs[len(assign.Lhs)-1].End()), End: p.offset(assign.Lhs[len(assign.Lhs)-1].End()), NewText: " " + newTypes[0]},
+			tok,
+		}
+	}
+
+	// Declaring the variables before the assignment moves the start of
+	// their scope: the right-hand side must not refer to a variable they
+	// would shadow.
+	if mentions(p, assign.Rhs, newNames) {
+		return nil
+	}
+
+	var decls strings.Builder
+	for i = range newNames {
+		decls.WriteString("var " + newNames[i] + " " + newTypes[i] + "\n")
+	}
+
+	return []syntax.TextEdit{
+		{Start: p.offset(assign.Pos()), End: p.offset(assign.Pos()), NewText: decls.String()},
+		tok,
+	}
+}
+
+// shortVarDeclRange hoists the variables of a range statement before the
+// statement stmt (the range itself or its label) and turns ':=' into '='.
+// following are the statements that come after stmt in the same block.
+func shortVarDeclRange(p *Package, q *qualifier, stmt ast.Stmt, rangeStmt *ast.RangeStmt, following []ast.Stmt) []syntax.TextEdit {
+	var scope *types.Scope
+	scope = p.Info.Scopes[rangeStmt]
+	if scope == nil || scope.Parent() == nil {
+		return nil
+	}
+	var block *types.Scope
+	block = scope.Parent()
+
+	var vars []*types.Var
+	var decls strings.Builder
+	var expr ast.Expr
+	for _, expr = range []ast.Expr{rangeStmt.Key, rangeStmt.Value} {
+		if expr == nil {
+			continue
+		}
+		var ident *ast.Ident
+		var ok bool
+		ident, ok = expr.(*ast.Ident)
+		if !ok {
+			return nil
+		}
+		if ident.Name == "_" {
+			continue
+		}
+
+		var v *types.Var
+		v, ok = p.Info.Defs[ident].(*types.Var)
+		if !ok {
+			return nil
+		}
+
+		if !hoistable(p, block, v.Name(), rangeStmt.Pos(), following) {
+			return nil
+		}
+		var outer types.Object
+		_, outer = block.LookupParent(v.Name(), rangeStmt.Pos())
+		if outer != nil && refers(p, rangeStmt.X, outer) {
+			return nil
+		}
+
+		var typ string
+		typ, ok = q.typeString(v.Type(), stmt.Pos())
+		if !ok {
+			return nil
+		}
+		vars = append(vars, v)
+		decls.WriteString("var " + v.Name() + " " + typ + "\n")
+	}
+	if len(vars) == 0 {
+		return nil
+	}
+
+	// Range variables are per-iteration: hoisting them is only safe when
+	// no closure, goroutine or pointer can observe them across iterations.
+	if escapes(p, rangeStmt.Body, vars) {
+		return nil
+	}
+
+	var v *types.Var
+	for _, v = range vars {
+		p.markHoisted(block, v.Name())
+	}
+
+	return []syntax.TextEdit{
+		{Start: p.offset(stmt.Pos()), End: p.offset(stmt.Pos()), NewText: decls.String()},
+		{Start: p.offset(rangeStmt.TokPos), End: p.offset(rangeStmt.TokPos) + len(token.DEFINE.String()), NewText: "="},
+	}
+}
+
+// containsGoto reports whether file has goto statements, which forbid
+// adding declarations they could jump over.
+func containsGoto(file *ast.File) bool {
+	var found bool
+	ast.Inspect(file, func(n ast.Node) bool {
+		var branch *ast.BranchStmt
+		var ok bool
+		branch, ok = n.(*ast.BranchStmt)
+		if ok && branch.Tok == token.GOTO {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// hoistable reports whether a variable name, declared by a statement at pos,
+// can be declared in the enclosing block instead. It must neither collide
+// with a declaration of the block, including the ones hoisted there
+// already, nor hide an outer variable used by the following statements.
+func hoistable(p *Package, block *types.Scope, name string, pos token.Pos, following []ast.Stmt) bool {
+	if block.Lookup(name) != nil || p.hoisted[block][name] {
+		return false
+	}
+	var outer types.Object
+	_, outer = block.LookupParent(name, pos)
+	return outer == nil || !usesAfter(p, following, outer)
+}
+
+// markHoisted records that name was hoisted into block.
+func (p *Package) markHoisted(block *types.Scope, name string) {
+	if p.hoisted[block] == nil {
+		p.hoisted[block] = make(map[string]bool)
+	}
+	p.hoisted[block][name] = true
+}
+
+// usesAfter reports whether obj is referenced by stmts, either by name or
+// through a naked return.
+func usesAfter(p *Package, stmts []ast.Stmt, obj types.Object) bool {
+	var found bool
+	var stmt ast.Stmt
+	for _, stmt = range stmts {
+		ast.Inspect(stmt, func(n ast.Node) bool {
+			switch node := n.(type) {
+			case *ast.Ident:
+				if p.Info.Uses[node] == obj {
+					found = true
+				}
+			case *ast.ReturnStmt:
+				if len(node.Results) == 0 {
+					found = true
+				}
+			}
+			return !found
+		})
+	}
+	return found
+}
+
+// mentions reports whether exprs refer to a name of names, whatever it
+// denotes.
+func mentions(p *Package, exprs []ast.Expr, names []string) bool {
+	var found bool
+	var expr ast.Expr
+	for _, expr = range exprs {
+		ast.Inspect(expr, func(n ast.Node) bool {
+			var ident *ast.Ident
+			var ok bool
+			ident, ok = n.(*ast.Ident)
+			if ok && p.Info.Uses[ident] != nil && contains(names, ident.Name) {
+				found = true
+			}
+			return !found
+		})
+	}
+	return found
+}
+
+// refers reports whether obj is referenced inside n.
+func refers(p *Package, n ast.Node, obj types.Object) bool {
+	var found bool
+	ast.Inspect(n, func(n ast.Node) bool {
+		var ident *ast.Ident
+		var ok bool
+		ident, ok = n.(*ast.Ident)
+		if ok && p.Info.Uses[ident] == obj {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// escapes reports whether one of vars is captured by a function literal,
+// a go or defer statement, or has its address taken inside body.
+func escapes(p *Package, body *ast.BlockStmt, vars []*types.Var) bool {
+	var mentions func(n ast.Node) bool
+	mentions = func(n ast.Node) bool {
+		var found bool
+		ast.Inspect(n, func(n ast.Node) bool {
+			var ident *ast.Ident
+			var ok bool
+			ident, ok = n.(*ast.Ident)
+			if ok {
+				var v *types.Var
+				v, ok = p.Info.Uses[ident].(*types.Var)
+				if ok && containsVar(vars, v) {
+					found = true
+				}
+			}
+			return !found
+		})
+		return found
+	}
+
+	var found bool
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.FuncLit, *ast.GoStmt, *ast.DeferStmt:
+			found = found || mentions(node)
+			return false
+		case *ast.UnaryExpr:
+			if node.Op == token.AND && mentions(node.X) {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+func contains(list []string, s string) bool {
+	var item string
+	for _, item = range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsVar(list []*types.Var, v *types.Var) bool {
+	var item *types.Var
+	for _, item = range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+package fix
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"github.com/thierry-f-78/go-syntax/pkg/rules"
+	syntax "github.com/thierry-f-78/go-syntax/pkg/types"
+)
+
+// VarNoType inserts the inferred type into the var declarations of file
+// flagged by rules.VarNoTypeRule:
+//
+//	var r = strings.Split(s, ",")  ->  var r []string = strings.Split(s, ",")
+//	var a, b = 1, "s"              ->  var a int = 1
+//	                                   var b string = "s"
+//	var n, err = f()               ->  var n int
+//	                                   var err error
+//	                                   n, err = f()
+//
+// The last form is only possible inside a function body, outside of a
+// grouped declaration; such specs are left as is elsewhere.
+func VarNoType(p *Package, file *ast.File) []Suggestion {
+	var reported map[token.Pos]bool
+	reported = flagged(p, file, (&rules.VarNoTypeRule{}).CheckTyped(p.Fset, file, p.Types, p.Info))
+
+	var suggestions []Suggestion
+	var q *qualifier
+	q = newQualifier(p, file)
+
+	var fixDecl func(decl *ast.GenDecl, local bool)
+	fixDecl = func(decl *ast.GenDecl, local bool) {
+		if decl.Tok != token.VAR {
+			return
+		}
+		var spec ast.Spec
+		for _, spec = range decl.Specs {
+			var valueSpec *ast.ValueSpec
+			var ok bool
+			valueSpec, ok = spec.(*ast.ValueSpec)
+			if ok && reported[valueSpec.Pos()] {
+				q.begin()
+				var edits []syntax.TextEdit
+				edits = varNoTypeSpec(p, q, decl, valueSpec, local)
+				if edits != nil {
+					suggestions = suggest(suggestions, "var-no-type", "Add the explicit type of the variables", append(edits, q.commit()...), valueSpec.Pos())
+				}
+			}
+		}
+	}
+
+	var decl ast.Decl
+	for _, decl = range file.Decls {
+		var gen *ast.GenDecl
+		var ok bool
+		gen, ok = decl.(*ast.GenDecl)
+		if ok {
+			fixDecl(gen, false)
+		}
+	}
+	ast.Inspect(file, func(n ast.Node) bool {
+		var declStmt *ast.DeclStmt
+		var ok bool
+		declStmt, ok = n.(*ast.DeclStmt)
+		if ok {
+			fixDecl(declStmt.Decl.(*ast.GenDecl), true)
+		}
+		return true
+	})
+
+	return suggestions
+}
+
+func varNoTypeSpec(p *Package, q *qualifier, decl *ast.GenDecl, spec *ast.ValueSpec, local bool) []syntax.TextEdit {
+	var names []string
+	var typeNames []string
+	var objTypes []types.Type
+
+	var name *ast.Ident
+	for _, name = range spec.Names {
+		var obj types.Object
+		obj = p.Info.Defs[name]
+		if obj == nil {
+			return nil
+		}
+
+		var typ string
+		var ok bool
+		typ, ok = q.typeString(obj.Type(), spec.Pos())
+		if !ok {
+			return nil
+		}
+		names = append(names, name.Name)
+		typeNames = append(typeNames, typ)
+		objTypes = append(objTypes, obj.Type())
+	}
+
+	var sameType bool = true
+	var i int
+	for i = 1; i < len(objTypes); i++ {
+		if !types.Identical(objTypes[i], objTypes[0]) {
+			sameType = false
+		}
+	}
+
+	var lastName token.Pos
+	lastName = spec.Names[len(spec.Names)-1].End()
+
+	// var a, b = 1, 2  ->  var a, b int = 1, 2
+	if sameType {
+		return []syntax.TextEdit{{Start: p.offset(lastName), End: p.offset(lastName), NewText: " " + typeNames[0]}}
+	}
+
+	// Declaring the variables one by one, or before their values, starts
+	// their scope earlier: inside a function, the values must not refer
+	// to a variable they would shadow.
+	if local && mentions(p, spec.Values, names) {
+		return nil
+	}
+
+	var prefix string
+	if !decl.Lparen.IsValid() {
+		prefix = "var "
+	}
+
+	// var a, b = 1, "s"  ->  one spec per name, keeping each value.
+	if len(spec.Values) == len(spec.Names) {
+		var edits []syntax.TextEdit
+		edits = append(edits, syntax.TextEdit{
+			Start:   p.offset(spec.Names[0].Pos()),
+			End:     p.offset(spec.Values[0].Pos()),
+			NewText: names[0] + " " + typeNames[0] + " = ",
+		})
+		for i = 1; i < len(names); i++ {
+			edits = append(edits, syntax.TextEdit{
+				Start:   p.offset(spec.Values[i-1].End()),
+				End:     p.offset(spec.Values[i].Pos()),
+				NewText: "\n" + prefix + names[i] + " " + typeNames[i] + " = ",
+			})
+		}
+		return edits
+	}
+
+	// var a, b = f()  ->  declarations followed by an assignment.
+	if !local || decl.Lparen.IsValid() {
+		return nil
+	}
+	var decls strings.Builder
+	for i = range names {
+		if names[i] != "_" {
+			decls.WriteString("var " + names[i] + " " + typeNames[i] + "\n")
+		}
+	}
+	decls.WriteString(strings.Join(names, ", ") + " = ")
+	return []syntax.TextEdit{{
+		Start:   p.offset(decl.Pos()),
+		End:     p.offset(spec.Values[0].Pos()),
+		NewText: decls.String(),
+	}}
+}
@@ -0,0 +1,179 @@
+// Package golangci is the golangci-lint module plugin of go-syntax. It
+// registers the plugin "go-syntax", whose settings take the rules section
+// of the .go-syntax.yml files:
+//
+//	linters-settings:
+//	  custom:
+//	    go-syntax:
+//	      type: module
+//	      settings:
+//	        rules:
+//	          named-returns: false
+//	          naked-return:
+//	            max-func-lines: 5
+//
+// Each enabled rule is an analyzer. The //nolint comments naming the
+// rules, such as //nolint:short-var-decl, are honoured by the analyzers
+// themselves.
+package golangci
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/golangci/plugin-module-register/register"
+	"golang.org/x/tools/go/analysis"
+
+	"github.com/thierry-f-78/go-syntax/pkg/analyzer"
+	"github.com/thierry-f-78/go-syntax/pkg/linter"
+	"github.com/thierry-f-78/go-syntax/pkg/rules"
+)
+
+func init() {
+	register.Plugin("go-syntax", New)
+}
+
+// Settings are the settings of the plugin.
+type Settings struct {
+	// Rules enable or disable the rules, with a boolean, or set their
+	// options, with a mapping that may include "enabled".
+	Rules map[string]interface{} `json:"rules"`
+}
+
+// Plugin is the go-syntax plugin, configured by its settings.
+type Plugin struct {
+	options linter.Options
+}
+
+// New returns the plugin configured by the raw settings of golangci-lint.
+func New(settings interface{}) (register.LinterPlugin, error) {
+	var s Settings
+	var err error
+	s, err = register.DecodeSettings[Settings](settings)
+	if err != nil {
+		return nil, err
+	}
+
+	var options linter.Options
+	options, err = linterOptions(s)
+	if err != nil {
+		return nil, err
+	}
+
+	// The rules and their options are checked against the registry.
+	_, err = linter.NewWithOptions(options)
+	if err != nil {
+		return nil, fmt.Errorf("go-syntax: %w", err)
+	}
+	return &Plugin{options: options}, nil
+}
+
+// linterOptions returns the rules selected by s, in registration order,
+// and their options.
+func linterOptions(s Settings) (linter.Options, error) {
+	var enabled map[string]bool
+	enabled = make(map[string]bool)
+	var info rules.Info
+	for _, info = range rules.All() {
+		enabled[info.Name] = info.Enabled
+	}
+
+	var ruleOptions map[string]map[string]interface{}
+	ruleOptions = make(map[string]map[string]interface{})
+
+	var names []string
+	var name string
+	for name = range s.Rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name = range names {
+		var ok bool
+		_, ok = rules.Lookup(name)
+		if !ok {
+			return linter.Options{}, fmt.Errorf("go-syntax: unknown rule %q", name)
+		}
+
+		switch value := s.Rules[name].(type) {
+		case bool:
+			enabled[name] = value
+		case map[string]interface{}:
+			var option string
+			var optionValue interface{}
+			for option, optionValue = range value {
+				if option != "enabled" {
+					if ruleOptions[name] == nil {
+						ruleOptions[name] = make(map[string]interface{})
+					}
+					ruleOptions[name][option] = jsonValue(optionValue)
+					continue
+				}
+				var b bool
+				b, ok = optionValue.(bool)
+				if !ok {
+					return linter.Options{}, fmt.Errorf("go-syntax: rule %q: enabled expects a boolean, got %v", name, optionValue)
+				}
+				enabled[name] = b
+			}
+		default:
+			return linter.Options{}, fmt.Errorf("go-syntax: rule %q expects a boolean or a mapping, got %v", name, value)
+		}
+	}
+
+	var options linter.Options
+	options = linter.Options{RuleOptions: make(map[string]map[string]interface{})}
+	for _, info = range rules.All() {
+		if !enabled[info.Name] {
+			continue
+		}
+		options.Rules = append(options.Rules, info.Name)
+		if ruleOptions[info.Name] != nil {
+			options.RuleOptions[info.Name] = ruleOptions[info.Name]
+		}
+	}
+	return options, nil
+}
+
+// jsonValue converts the whole numbers, which the settings decode as
+// float64, to int as the rules expect.
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) <= math.MaxInt32 {
+			return int(v)
+		}
+	case []interface{}:
+		var converted []interface{}
+		var item interface{}
+		for _, item = range v {
+			converted = append(converted, jsonValue(item))
+		}
+		return converted
+	}
+	return value
+}
+
+// BuildAnalyzers returns an analyzer for each enabled rule.
+func (p *Plugin) BuildAnalyzers() ([]*analysis.Analyzer, error) {
+	var analyzers []*analysis.Analyzer
+	var name string
+	for _, name = range p.options.Rules {
+		var info rules.Info
+		info, _ = rules.Lookup(name)
+
+		var a *analysis.Analyzer
+		var err error
+		a, err = analyzer.NewWithOptions(info, p.options.RuleOptions[name])
+		if err != nil {
+			return nil, err
+		}
+		analyzers = append(analyzers, a)
+	}
+	return analyzers, nil
+}
+
+// GetLoadMode asks for the type information, which the fixes need.
+func (p *Plugin) GetLoadMode() string {
+	return register.LoadModeTypesInfo
+}
@@ -0,0 +1,115 @@
+package golangci
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golangci/plugin-module-register/register"
+	"golang.org/x/tools/go/analysis"
+)
+
+func TestPlugin(t *testing.T) {
+	var newPlugin register.NewPlugin
+	var err error
+	newPlugin, err = register.GetPlugin("go-syntax")
+	if err != nil {
+		t.Fatalf("Plugin not registered: %v", err)
+	}
+
+	// The settings as golangci-lint passes them, decoded from YAML.
+	var plugin register.LinterPlugin
+	plugin, err = newPlugin(map[string]interface{}{
+		"rules": map[string]interface{}{
+			"named-returns": false,
+			"naked-return": map[string]interface{}{
+				"max-func-lines": 5,
+			},
+			"if-init": map[string]interface{}{
+				"enabled":         true,
+				"allow-err-check": true,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create plugin: %v", err)
+	}
+
+	var analyzers []*analysis.Analyzer
+	analyzers, err = plugin.BuildAnalyzers()
+	if err != nil {
+		t.Fatalf("Failed to build analyzers: %v", err)
+	}
+
+	var names []string
+	var a *analysis.Analyzer
+	for _, a = range analyzers {
+		names = append(names, a.Name)
+	}
+	var expected string = "short_var_decl,var_no_type,const_no_type,naked_return,if_init"
+	if strings.Join(names, ",") != expected {
+		t.Errorf("Expected analyzers %s, got %s", expected, strings.Join(names, ","))
+	}
+	if plugin.GetLoadMode() != register.LoadModeTypesInfo {
+		t.Errorf("Expected load mode %s, got %s", register.LoadModeTypesInfo, plugin.GetLoadMode())
+	}
+}
+
+func TestPluginSettingsErrors(t *testing.T) {
+	var tests []struct {
+		name     string
+		settings interface{}
+		expected string
+	}
+	tests = []struct {
+		name     string
+		settings interface{}
+		expected string
+	}{
+		{
+			name:     "unknown key",
+			settings: map[string]interface{}{"rule": map[string]interface{}{}},
+			expected: `decoding settings: json: unknown field "rule"`,
+		},
+		{
+			name:     "unknown rule",
+			settings: map[string]interface{}{"rules": map[string]interface{}{"no-goto": true}},
+			expected: `go-syntax: unknown rule "no-goto"`,
+		},
+		{
+			name: "unknown option",
+			settings: map[string]interface{}{"rules": map[string]interface{}{
+				"if-init": map[string]interface{}{"allow-all": true},
+			}},
+			expected: `go-syntax: rule "if-init": unknown option "allow-all"`,
+		},
+		{
+			name: "invalid option value",
+			settings: map[string]interface{}{"rules": map[string]interface{}{
+				"naked-return": map[string]interface{}{"max-func-lines": 1.5},
+			}},
+			expected: `go-syntax: rule "naked-return": option "max-func-lines" expects a non-negative integer, got 1.5`,
+		},
+		{
+			name: "invalid rule value",
+			settings: map[string]interface{}{"rules": map[string]interface{}{
+				"if-init": "yes",
+			}},
+			expected: `go-syntax: rule "if-init" expects a boolean or a mapping, got yes`,
+		},
+	}
+
+	var tt struct {
+		name     string
+		settings interface{}
+		expected string
+	}
+	for _, tt = range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			_, err = New(tt.settings)
+			if err == nil || err.Error() != tt.expected {
+				t.Errorf("Expected error %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,365 @@
+package linter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"go/build"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/thierry-f-78/go-syntax/pkg/cache"
+	"github.com/thierry-f-78/go-syntax/pkg/loader"
+	"github.com/thierry-f-78/go-syntax/pkg/types"
+)
+
+// SetCache makes Lint keep the issues of the files in c, and reuse them
+// while the files, the other files of their directory, the packages they
+// import, their rules and go-syntax do not change. nil disables the
+// cache, the default.
+func (l *Linter) SetCache(c *cache.Cache) {
+	l.cache = c
+}
+
+// cacheEntry is the cached result of a file.
+type cacheEntry struct {
+	Issues []types.Issue
+}
+
+// lookup sets the issues of the files of results found in the cache. The
+// files of a directory are linted anew together when one of them is not
+// found, for the package rules to see them all.
+func (l *Linter) lookup(ctx context.Context, r *lintRun, files []string, results []fileResult) {
+	// The typed and package rules depend on the whole package.
+	var dirs []string
+	var seen map[string]bool
+	seen = make(map[string]bool)
+	var i int
+	var file string
+	for i, file = range files {
+		if !results[i].failed && needsPackage(results[i].rules) && !seen[filepath.Dir(file)] {
+			seen[filepath.Dir(file)] = true
+			dirs = append(dirs, filepath.Dir(file))
+		}
+	}
+	var dirHashes []*[]byte
+	dirHashes = make([]*[]byte, len(dirs))
+	parallel(ctx, l.workers, len(dirs), func(i int) {
+		var sum []byte
+		var err error
+		sum, err = l.hashDir(r, dirs[i])
+		if err == nil {
+			dirHashes[i] = &sum
+		}
+	})
+	var byDir map[string]*[]byte
+	byDir = make(map[string]*[]byte)
+	var imports map[string][]byte
+	imports = make(map[string][]byte)
+	for i = range dirs {
+		if dirHashes[i] == nil {
+			continue
+		}
+		// The types of the package depend on the packages it imports.
+		var sum []byte
+		var err error
+		sum, err = l.hashImports(r, dirs[i], imports)
+		if err != nil {
+			continue
+		}
+		sum = append(*dirHashes[i], sum...)
+		byDir[dirs[i]] = &sum
+	}
+
+	parallel(ctx, l.workers, len(files), func(i int) {
+		if results[i].failed {
+			return
+		}
+		var dirHash []byte
+		if needsPackage(results[i].rules) {
+			if byDir[filepath.Dir(files[i])] == nil {
+				return
+			}
+			dirHash = *byDir[filepath.Dir(files[i])]
+		}
+		var src []byte
+		var err error
+		src, err = r.readFile(files[i])
+		if err != nil {
+			return
+		}
+
+		var key cache.Key
+		key = l.cacheKey(files[i], src, results[i].rules, dirHash)
+		results[i].key = &key
+		var data []byte
+		var ok bool
+		data, ok = l.cache.Get(key)
+		if !ok {
+			return
+		}
+		var entry cacheEntry
+		err = json.Unmarshal(data, &entry)
+		if err != nil {
+			return
+		}
+		results[i].issues = entry.Issues
+		results[i].cached = true
+	})
+
+	var missed map[string]bool
+	missed = make(map[string]bool)
+	for i, file = range files {
+		if !results[i].failed && !results[i].cached {
+			missed[filepath.Dir(file)] = true
+		}
+	}
+	for i, file = range files {
+		if results[i].cached && missed[filepath.Dir(file)] {
+			results[i].cached = false
+			results[i].issues = nil
+		}
+	}
+}
+
+// store keeps the issues of the files of results linted in this run.
+func (l *Linter) store(ctx context.Context, results []fileResult) {
+	parallel(ctx, l.workers, len(results), func(i int) {
+		if results[i].key == nil || results[i].cached || results[i].failed || results[i].transient {
+			return
+		}
+		var data []byte
+		var err error
+		data, err = json.Marshal(cacheEntry{Issues: results[i].issues})
+		if err != nil {
+			return
+		}
+		// The cache is an optimization: a failure to write is ignored.
+		_ = l.cache.Put(*results[i].key, data)
+	})
+}
+
+// needsPackage tells whether fileRules look at the other files of the
+// package.
+func needsPackage(fileRules []types.Rule) bool {
+	var rule types.Rule
+	for _, rule = range fileRules {
+		var ok bool
+		_, ok = rule.(types.PackageRule)
+		if ok {
+			return true
+		}
+	}
+	return hasTypedRule(fileRules)
+}
+
+// cacheKey returns the key of the issues of filename, whose content is
+// src. dirHash is the hash of its directory and of the packages it
+// imports, if its rules depend on them.
+func (l *Linter) cacheKey(filename string, src []byte, fileRules []types.Rule, dirHash []byte) cache.Key {
+	var ctxt *build.Context = l.ctxt
+	if ctxt == nil {
+		ctxt = &build.Default
+	}
+
+	var h hash.Hash
+	h = sha256.New()
+	fmt.Fprintf(h, "go-syntax %s\n", toolID())
+	fmt.Fprintf(h, "build %s %s %q\n", ctxt.GOOS, ctxt.GOARCH, ctxt.BuildTags)
+	var rule types.Rule
+	for _, rule = range fileRules {
+		// The options of the rules are their fields.
+		fmt.Fprintf(h, "rule %s %#v\n", rule.Name(), rule)
+	}
+	fmt.Fprintf(h, "package %x\n", dirHash)
+	fmt.Fprintf(h, "file %q %d\n", filename, len(src))
+	h.Write(src)
+
+	var key cache.Key
+	copy(key[:], h.Sum(nil))
+	return key
+}
+
+// hashDir returns the hash of the Go files of dir, those of the overlay
+// included.
+func (l *Linter) hashDir(r *lintRun, dir string) ([]byte, error) {
+	var entries []os.DirEntry
+	var err error
+	entries, err = os.ReadDir(dir)
+	var inOverlay []string
+	inOverlay = r.overlayFiles(dir)
+	if err != nil && len(inOverlay) == 0 {
+		return nil, err
+	}
+	var names []string
+	var entry os.DirEntry
+	for _, entry = range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".go") && !contains(inOverlay, entry.Name()) {
+			names = append(names, entry.Name())
+		}
+	}
+	names = append(names, inOverlay...)
+	sort.Strings(names)
+
+	var h hash.Hash
+	h = sha256.New()
+	var name string
+	for _, name = range names {
+		var src []byte
+		src, err = r.readFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(h, "file %q %d\n", name, len(src))
+		h.Write(src)
+	}
+	return h.Sum(nil), nil
+}
+
+// hashImports returns the hash of the packages imported by the package
+// in dir, directly or not, its tests included. Those of GOROOT and of the
+// module cache are identified by their directory, the others by their
+// files. hashes keeps the hashes of the directories across calls.
+func (l *Linter) hashImports(r *lintRun, dir string, hashes map[string][]byte) ([]byte, error) {
+	var ctxt *build.Context
+	ctxt = l.buildContext(r.overlay)
+
+	var imp *loader.Importer
+	var generation int
+	imp, generation = l.takeImporter()
+	defer l.releaseImporter(imp, generation)
+
+	var abs string
+	var err error
+	abs, err = filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	var bp *build.Package
+	bp, err = ctxt.ImportDir(abs, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var deps map[string]string
+	deps = make(map[string]string)
+	var pending []string
+	var from map[string]string
+	from = make(map[string]string)
+	var path string
+	for _, path = range append(append(append([]string{}, bp.Imports...), bp.TestImports...), bp.XTestImports...) {
+		if from[path] == "" {
+			from[path] = abs
+			pending = append(pending, path)
+		}
+	}
+	// The dependencies are those of the module of dir, as for the
+	// importer.
+	for len(pending) > 0 {
+		path, pending = pending[0], pending[1:]
+		var depDir string
+		depDir, err = imp.Dir(path, from[path], abs)
+		if err != nil {
+			deps[path] = "missing"
+			continue
+		}
+		deps[path] = depDir
+		// The standard library imports nothing else.
+		if depDir == abs || strings.HasPrefix(depDir, filepath.Join(ctxt.GOROOT, "src")+string(filepath.Separator)) {
+			continue
+		}
+		var dep *build.Package
+		dep, err = ctxt.ImportDir(depDir, 0)
+		if err != nil {
+			continue
+		}
+		var imported string
+		for _, imported = range dep.Imports {
+			if from[imported] == "" {
+				from[imported] = depDir
+				pending = append(pending, imported)
+			}
+		}
+	}
+
+	var paths []string
+	for path = range deps {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var h hash.Hash
+	h = sha256.New()
+	fmt.Fprintf(h, "goroot %q %s\n", ctxt.GOROOT, gorootVersion(ctxt))
+	for _, path = range paths {
+		var depDir string = deps[path]
+		fmt.Fprintf(h, "import %q %q\n", path, depDir)
+		if depDir == "missing" || depDir == abs || imp.Immutable(depDir) {
+			continue
+		}
+		var sum []byte
+		var ok bool
+		sum, ok = hashes[depDir]
+		if !ok {
+			sum, err = l.hashDir(r, depDir)
+			if err != nil {
+				return nil, err
+			}
+			hashes[depDir] = sum
+		}
+		h.Write(sum)
+	}
+	return h.Sum(nil), nil
+}
+
+// gorootVersion returns the version of the Go distribution of ctxt, as
+// written in its VERSION file, if any.
+func gorootVersion(ctxt *build.Context) string {
+	var data []byte
+	var err error
+	data, err = os.ReadFile(filepath.Join(ctxt.GOROOT, "VERSION"))
+	if err != nil {
+		return ""
+	}
+	return strings.SplitN(string(data), "\n", 2)[0]
+}
+
+var toolIDOnce sync.Once
+var toolIDValue string
+
+// toolID identifies the build of go-syntax: its version, along with the
+// hash of the program for the development builds.
+func toolID() string {
+	toolIDOnce.Do(func() {
+		toolIDValue = Version()
+		if toolIDValue != "devel" {
+			return
+		}
+
+		var exe string
+		var err error
+		exe, err = os.Executable()
+		if err != nil {
+			return
+		}
+		var f *os.File
+		f, err = os.Open(exe)
+		if err != nil {
+			return
+		}
+		defer f.Close()
+		var h hash.Hash
+		h = sha256.New()
+		_, err = io.Copy(h, f)
+		if err == nil {
+			toolIDValue = fmt.Sprintf("devel %x", h.Sum(nil))
+		}
+	})
+	return toolIDValue
+}
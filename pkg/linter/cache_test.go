@@ -0,0 +1,135 @@
+package linter
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/thierry-f-78/go-syntax/pkg/cache"
+	"github.com/thierry-f-78/go-syntax/pkg/types"
+)
+
+func TestLintCache(t *testing.T) {
+	var dir string
+	dir = t.TempDir()
+	var a string
+	a = filepath.Join(dir, "a.go")
+	var b string
+	b = filepath.Join(dir, "b.go")
+	var err error
+	err = os.WriteFile(a, []byte("package p\n\nconst A string = \"a\"\nconst B string = \"b\"\n\nvar x = y\n"), 0o644)
+	if err == nil {
+		err = os.WriteFile(b, []byte("package p\n\nvar y string = A\n"), 0o644)
+	}
+	if err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	var c *cache.Cache
+	c, err = cache.Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open cache: %v", err)
+	}
+
+	var lint func() []types.Issue
+	lint = func() []types.Issue {
+		var linter *Linter
+		var err error
+		linter, err = NewWithOptions(Options{Rules: []string{"var-no-type", "test-unused-const"}})
+		if err != nil {
+			t.Fatalf("Failed to create linter: %v", err)
+		}
+		linter.SetCache(c)
+		return linter.Lint([]string{a, b})
+	}
+
+	var first []types.Issue
+	first = lint()
+	if len(first) != 2 {
+		t.Fatalf("Expected 2 issues, got %d: %v", len(first), first)
+	}
+	var stats cache.Stats
+	stats, err = c.Stats()
+	if err != nil || stats.Entries != 2 {
+		t.Fatalf("Expected 2 cache entries, got %+v, %v", stats, err)
+	}
+
+	var second []types.Issue
+	second = lint()
+	if !reflect.DeepEqual(second, first) {
+		t.Errorf("Expected the cached issues to match:\n%v\n%v", first, second)
+	}
+
+	// A change of b.go changes the issues of the package rule in a.go.
+	err = os.WriteFile(b, []byte("package p\n\nvar y string = B\n"), 0o644)
+	if err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	var third []types.Issue
+	third = lint()
+	if len(third) != 2 || third[1].Message != "Unused constant A" {
+		t.Errorf("Expected the issues of the changed package, got %v", third)
+	}
+}
+
+func TestLintCacheImports(t *testing.T) {
+	var dir string
+	dir = t.TempDir()
+	var a string
+	a = filepath.Join(dir, "a", "a.go")
+	var b string
+	b = filepath.Join(dir, "b", "b.go")
+	var err error
+	err = os.MkdirAll(filepath.Dir(a), 0o755)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(b), 0o755)
+	}
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/m\n\ngo 1.22\n"), 0o644)
+	}
+	if err == nil {
+		err = os.WriteFile(a, []byte("package a\n\nimport \"example.com/m/b\"\n\nvar x = b.W\n"), 0o644)
+	}
+	if err == nil {
+		err = os.WriteFile(b, []byte("package b\n"), 0o644)
+	}
+	if err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	var c *cache.Cache
+	c, err = cache.Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open cache: %v", err)
+	}
+
+	var lint func() []types.Issue
+	lint = func() []types.Issue {
+		var linter *Linter
+		var err error
+		linter, err = NewWithOptions(Options{Rules: []string{"var-no-type"}})
+		if err != nil {
+			t.Fatalf("Failed to create linter: %v", err)
+		}
+		linter.SetCache(c)
+		return linter.Lint([]string{a})
+	}
+
+	// b.W does not exist: the syntactic check takes it for a type.
+	var issues []types.Issue
+	issues = lint()
+	if len(issues) != 0 {
+		t.Fatalf("Expected no issues, got %v", issues)
+	}
+
+	// Once b.W is a variable of b, a changes but for its imports.
+	err = os.WriteFile(b, []byte("package b\n\nvar W int\n"), 0o644)
+	if err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	issues = lint()
+	if len(issues) != 1 || issues[0].Rule != "var-no-type" {
+		t.Errorf("Expected the var-no-type issue of the changed import, got %v", issues)
+	}
+}
@@ -0,0 +1,298 @@
+package linter
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/thierry-f-78/go-syntax/pkg/fix"
+	"github.com/thierry-f-78/go-syntax/pkg/types"
+)
+
+// FixResult is the outcome of applying the suggested fixes of one file.
+type FixResult struct {
+	File   string
+	Before []byte
+	After  []byte
+
+	// Applied lists the issues whose fix was applied, Skipped the ones
+	// whose fix conflicts with a fix applied before.
+	Applied []types.Issue
+	Skipped []types.Issue
+}
+
+// issueKey identifies an issue by its position and rule.
+type issueKey struct {
+	file   string
+	line   int
+	column int
+	rule   string
+}
+
+// attachFixes sets the suggested fix of the issues pkg/fix can fix, and
+// the reason why it declined to fix the others. files are the files the
+// issues were reported for, their packages made of the files ctxt
+// selects.
+func attachFixes(ctxt *build.Context, issues []types.Issue, files []string) {
+	var targets map[string]bool
+	targets = make(map[string]bool)
+	var file string
+	for _, file = range files {
+		var abs string
+		var err error
+		abs, err = filepath.Abs(file)
+		if err == nil {
+			targets[abs] = true
+		}
+	}
+
+	var pkgs []*fix.Package
+	var loadErr error
+	pkgs, loadErr = fix.LoadContext(ctxt, files)
+
+	var fixes map[issueKey]*types.SuggestedFix
+	fixes = make(map[issueKey]*types.SuggestedFix)
+	var reasons map[issueKey]string
+	reasons = make(map[issueKey]string)
+	var loaded map[string]bool
+	loaded = make(map[string]bool)
+
+	// The fixers share the state of the package, so they run in a fixed
+	// order for the fixes to be the same from one run to the next.
+	var names []string
+	var name string
+	for name = range fix.Fixers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var pkg *fix.Package
+	for _, pkg = range pkgs {
+		var astFile *ast.File
+		for _, astFile = range pkg.Files {
+			if !targets[pkg.Filename(astFile)] {
+				continue
+			}
+			loaded[pkg.Filename(astFile)] = true
+
+			for _, name = range names {
+				var suggestion fix.Suggestion
+				for _, suggestion = range fix.Fixers[name](pkg, astFile) {
+					var suggested *types.SuggestedFix
+					suggested = new(types.SuggestedFix)
+					*suggested = suggestion.Fix
+					var pos token.Pos
+					for _, pos = range suggestion.Positions {
+						fixes[positionKey(pkg.Fset.Position(pos), suggestion.Rule)] = suggested
+					}
+				}
+			}
+		}
+
+		var refusal fix.Refusal
+		for _, refusal = range pkg.Refusals {
+			reasons[positionKey(refusal.Pos, refusal.Rule)] = refusal.Reason
+		}
+	}
+
+	var i int
+	for i = range issues {
+		var abs string
+		var err error
+		abs, err = filepath.Abs(issues[i].File)
+		if err != nil {
+			continue
+		}
+
+		var key issueKey
+		key = issueKey{file: abs, line: issues[i].Line, column: issues[i].Column, rule: issues[i].Rule}
+		issues[i].Fix = fixes[key]
+		issues[i].NoFixReason = reasons[key]
+		if !loaded[abs] && loadErr != nil && fix.Fixable(issues[i].Rule) {
+			issues[i].NoFixReason = "its package could not be type-checked"
+		}
+	}
+}
+
+func positionKey(pos token.Position, rule string) issueKey {
+	return issueKey{file: pos.Filename, line: pos.Line, column: pos.Column, rule: rule}
+}
+
+// ApplyFixes applies the suggested fixes of issues to their files, in
+// the order of issues, and returns the result for each file having fixes.
+// The files are read as by Lint, from the overlay set by SetOverlay if
+// there. A fix is applied entirely or not at all: it is skipped when one
+// of its edits overlaps an edit of a fix applied before, unless both
+// edits are identical. Nothing is written to disk.
+func (l *Linter) ApplyFixes(issues []types.Issue) ([]FixResult, error) {
+	var results []*FixResult
+	var byFile map[string]*FixResult
+	byFile = make(map[string]*FixResult)
+	var accepted map[string][]types.TextEdit
+	accepted = make(map[string][]types.TextEdit)
+
+	var issue types.Issue
+	for _, issue = range issues {
+		if issue.Fix == nil {
+			continue
+		}
+
+		var result *FixResult
+		result = byFile[issue.File]
+		if result == nil {
+			var src []byte
+			var err error
+			src, err = l.ReadFile(issue.File)
+			if err != nil {
+				return nil, err
+			}
+			result = &FixResult{File: issue.File, Before: src}
+			byFile[issue.File] = result
+			results = append(results, result)
+		}
+
+		if conflicts(accepted[issue.File], issue.Fix.Edits) {
+			result.Skipped = append(result.Skipped, issue)
+			continue
+		}
+		accepted[issue.File] = append(accepted[issue.File], issue.Fix.Edits...)
+		result.Applied = append(result.Applied, issue)
+	}
+
+	var out []FixResult
+	var result *FixResult
+	for _, result = range results {
+		var err error
+		result.After, err = fix.Apply(result.Before, accepted[result.File])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", result.File, err)
+		}
+		out = append(out, *result)
+	}
+	return out, nil
+}
+
+// conflicts reports whether one of edits cannot be applied along with
+// the accepted ones. Insertions at the same offset are applied in order;
+// any other pair of distinct edits touching the same bytes, or starting
+// at the same offset, conflicts.
+func conflicts(accepted []types.TextEdit, edits []types.TextEdit) bool {
+	var edit types.TextEdit
+	for _, edit = range edits {
+		var other types.TextEdit
+		for _, other = range accepted {
+			if edit == other {
+				continue
+			}
+			if edit.Start == edit.End && other.Start == other.End {
+				continue
+			}
+			if edit.Start == other.Start || (edit.Start < other.End && other.Start < edit.End) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CheckFixes type-checks the packages of the files of results again, with
+// their fixed content, and returns an error when one of them no longer
+// type-checks. Each fix is made against the package as it was, so that
+// fixes applied together may still break it.
+func (l *Linter) CheckFixes(results []FixResult) error {
+	var overlay map[string][]byte
+	overlay = make(map[string][]byte)
+	var name string
+	var src []byte
+	for name, src = range l.overlay {
+		overlay[name] = src
+	}
+
+	var files []string
+	var result FixResult
+	for _, result = range results {
+		if bytes.Equal(result.Before, result.After) {
+			continue
+		}
+		overlay[absPath(result.File)] = result.After
+		files = append(files, result.File)
+	}
+	if len(files) == 0 {
+		return nil
+	}
+
+	var pkgs []*fix.Package
+	var err error
+	pkgs, err = fix.LoadContext(l.buildContext(overlay), files)
+
+	var loaded map[string]bool
+	loaded = make(map[string]bool)
+	var pkg *fix.Package
+	for _, pkg = range pkgs {
+		var file *ast.File
+		for _, file = range pkg.Files {
+			loaded[pkg.Filename(file)] = true
+		}
+	}
+	for _, name = range files {
+		if !loaded[absPath(name)] {
+			return fmt.Errorf("the fixes of %s break its package: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// WriteFixes writes the fixed content of the files of results. Each file
+// is replaced atomically, keeping its permissions.
+func WriteFixes(results []FixResult) error {
+	var result FixResult
+	for _, result = range results {
+		if bytes.Equal(result.Before, result.After) {
+			continue
+		}
+
+		var err error
+		err = writeFileAtomic(result.File, result.After)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFileAtomic replaces the content of filename through a temporary
+// file renamed over it, so that readers never see a partial write.
+func writeFileAtomic(filename string, content []byte) error {
+	var info os.FileInfo
+	var err error
+	info, err = os.Stat(filename)
+	if err != nil {
+		return err
+	}
+
+	var tmp *os.File
+	tmp, err = os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(content)
+	if err == nil {
+		err = tmp.Chmod(info.Mode().Perm())
+	}
+	if err == nil {
+		err = tmp.Close()
+	} else {
+		tmp.Close()
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
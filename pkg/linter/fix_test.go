@@ -0,0 +1,340 @@
+package linter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thierry-f-78/go-syntax/pkg/loader"
+	"github.com/thierry-f-78/go-syntax/pkg/types"
+)
+
+// writeTestFile writes code into a new file of a temporary directory.
+func writeTestFile(t *testing.T, code string) string {
+	t.Helper()
+
+	var filename string
+	filename = filepath.Join(t.TempDir(), "test.go")
+	var err error
+	err = os.WriteFile(filename, []byte(code), 0o644)
+	if err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	return filename
+}
+
+func TestLintAttachesFixes(t *testing.T) {
+	var filename string
+	filename = writeTestFile(t, `package main
+
+func main() {
+	x := 42
+	y := "s" //nolint:short-var-decl
+	println(x, y)
+}
+`)
+
+	var linter *Linter
+	linter = New()
+	linter.SetFixes(true)
+
+	var issues []types.Issue
+	issues = linter.Lint([]string{filename})
+	if len(issues) != 1 {
+		t.Fatalf("Expected 1 issue, got %d", len(issues))
+	}
+	if issues[0].Fix == nil {
+		t.Fatalf("Expected a suggested fix for %s", issues[0].Message)
+	}
+
+	var results []FixResult
+	var err error
+	results, err = linter.ApplyFixes(issues)
+	if err != nil {
+		t.Fatalf("Failed to apply fixes: %v", err)
+	}
+	if len(results) != 1 || len(results[0].Applied) != 1 {
+		t.Fatalf("Expected 1 file with 1 applied fix, got %v", results)
+	}
+	if !strings.Contains(string(results[0].After), "var x int = 42") || !strings.Contains(string(results[0].After), `y := "s"`) {
+		t.Errorf("Unexpected result:\n%s", results[0].After)
+	}
+
+	err = WriteFixes(results)
+	if err != nil {
+		t.Fatalf("Failed to write fixes: %v", err)
+	}
+	linter.SetFixes(false)
+	issues = linter.Lint([]string{filename})
+	if len(issues) != 0 {
+		t.Errorf("Expected 0 issues once fixed, got %d", len(issues))
+	}
+}
+
+func TestLintExplainsRefusedFixes(t *testing.T) {
+	var filename string
+	filename = writeTestFile(t, `package main
+
+func f() (_ int, err error) {
+	return
+}
+`)
+
+	var linter *Linter
+	linter = New()
+	linter.SetFixes(true)
+
+	var issue types.Issue
+	for _, issue = range linter.Lint([]string{filename}) {
+		if issue.Rule == "named-returns" && issue.Fix == nil && issue.NoFixReason == "" {
+			t.Errorf("Expected a reason for the missing fix of %s", issue.Message)
+		}
+	}
+}
+
+func TestApplyFixesSkipsConflicts(t *testing.T) {
+	var tests []struct {
+		name     string
+		edits    [][]types.TextEdit
+		skipped  int
+		expected string
+	}
+	tests = []struct {
+		name     string
+		edits    [][]types.TextEdit
+		skipped  int
+		expected string
+	}{
+		{
+			name: "overlapping_fix_is_skipped",
+			edits: [][]types.TextEdit{
+				{{Start: 17, End: 18, NewText: "b"}},
+				{{Start: 17, End: 22, NewText: "c = 2"}},
+			},
+			skipped:  1,
+			expected: "var b = 1",
+		},
+		{
+			name: "fix_is_skipped_as_a_whole",
+			edits: [][]types.TextEdit{
+				{{Start: 17, End: 18, NewText: "b"}},
+				{{Start: 0, End: 0, NewText: "// c\n"}, {Start: 17, End: 18, NewText: "c"}},
+			},
+			skipped:  1,
+			expected: "package main\n\nvar b = 1",
+		},
+		{
+			name: "identical_edits_are_merged",
+			edits: [][]types.TextEdit{
+				{{Start: 17, End: 18, NewText: "b"}},
+				{{Start: 17, End: 18, NewText: "b"}, {Start: 21, End: 22, NewText: "2"}},
+			},
+			skipped:  0,
+			expected: "var b = 2",
+		},
+		{
+			name: "insertions_at_the_same_offset_are_kept",
+			edits: [][]types.TextEdit{
+				{{Start: 18, End: 18, NewText: "b"}},
+				{{Start: 18, End: 18, NewText: "c"}},
+			},
+			skipped:  0,
+			expected: "var abc = 1",
+		},
+	}
+
+	var tt struct {
+		name     string
+		edits    [][]types.TextEdit
+		skipped  int
+		expected string
+	}
+	for _, tt = range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var filename string
+			filename = writeTestFile(t, "package main\nvar a = 1\n")
+
+			var issues []types.Issue
+			var edits []types.TextEdit
+			for _, edits = range tt.edits {
+				issues = append(issues, types.Issue{File: filename, Fix: &types.SuggestedFix{Edits: edits}})
+			}
+
+			var results []FixResult
+			var err error
+			results, err = New().ApplyFixes(issues)
+			if err != nil {
+				t.Fatalf("Failed to apply fixes: %v", err)
+			}
+			if len(results[0].Skipped) != tt.skipped {
+				t.Errorf("Expected %d skipped fixes, got %d", tt.skipped, len(results[0].Skipped))
+			}
+			if !strings.Contains(string(results[0].After), tt.expected) {
+				t.Errorf("Expected %q in result:\n%s", tt.expected, results[0].After)
+			}
+		})
+	}
+}
+
+func TestCheckFixesRejectsBrokenPackages(t *testing.T) {
+	var tests []struct {
+		name  string
+		after string
+		fails bool
+	}
+	tests = []struct {
+		name  string
+		after string
+		fails bool
+	}{
+		{
+			name:  "fixed_package_type_checks",
+			after: "package main\nvar a int = 1\n",
+			fails: false,
+		},
+		{
+			name:  "broken_package_is_rejected",
+			after: "package main\nvar a user = 1\n",
+			fails: true,
+		},
+	}
+
+	var tt struct {
+		name  string
+		after string
+		fails bool
+	}
+	for _, tt = range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var filename string
+			filename = writeTestFile(t, "package main\nvar a = 1\n")
+
+			var err error
+			err = New().CheckFixes([]FixResult{{File: filename, Before: []byte("package main\nvar a = 1\n"), After: []byte(tt.after)}})
+			if (err != nil) != tt.fails {
+				t.Errorf("Expected failure %v, got %v", tt.fails, err)
+			}
+		})
+	}
+}
+
+func TestLintPackagesFixesHonourBuildTags(t *testing.T) {
+	var dir string
+	dir = filepath.Dir(writeTestFile(t, "//go:build !extra\n\npackage main\n\nvar a = f()\n\nfunc f() int { return 1 }\n"))
+	var err error
+	err = os.WriteFile(filepath.Join(dir, "extra.go"), []byte("//go:build extra\n\npackage main\n\nvar a = f()\n\nfunc f() string { return \"\" }\n"), 0o644)
+	if err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	var config *loader.Config
+	config = &loader.Config{Dir: dir, Tags: []string{"extra"}}
+	var pkgs []*loader.Package
+	pkgs, err = config.Load(".")
+	if err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+
+	var l *Linter
+	l = New()
+	l.SetBuildContext(config.Context())
+	l.SetFixes(true)
+	var issues []types.Issue
+	issues = l.LintPackages(pkgs)
+	if len(issues) != 1 {
+		t.Fatalf("Expected 1 issue, got %d", len(issues))
+	}
+	if filepath.Base(issues[0].File) != "extra.go" {
+		t.Errorf("Expected the issue in extra.go, got %s", issues[0].File)
+	}
+	if issues[0].Fix == nil || issues[0].Fix.Edits[0].NewText != " string" {
+		t.Errorf("Expected a fix adding the string type, got %+v (%s)", issues[0].Fix, issues[0].NoFixReason)
+	}
+}
+
+func TestLintPackagesFixesImportWithBuildTags(t *testing.T) {
+	var dir string
+	dir = t.TempDir()
+	var files map[string]string
+	files = map[string]string{
+		"go.mod":     "module example.com/m\n\ngo 1.22\n",
+		"a/a.go":     "package a\n\nimport \"example.com/m/b\"\n\nvar x = b.F()\n",
+		"b/b.go":     "//go:build !extra\n\npackage b\n\nfunc F() int { return 1 }\n",
+		"b/extra.go": "//go:build extra\n\npackage b\n\nfunc F() string { return \"\" }\n",
+	}
+	var name string
+	var content string
+	for name, content = range files {
+		var err error
+		err = os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755)
+		if err == nil {
+			err = os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644)
+		}
+		if err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	var config *loader.Config
+	config = &loader.Config{Dir: dir, Tags: []string{"extra"}}
+	var pkgs []*loader.Package
+	var err error
+	pkgs, err = config.Load("./a")
+	if err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+
+	var l *Linter
+	l = New()
+	l.SetBuildContext(config.Context())
+	l.SetFixes(true)
+	var issues []types.Issue
+	issues = l.LintPackages(pkgs)
+	if len(issues) != 1 {
+		t.Fatalf("Expected 1 issue, got %d", len(issues))
+	}
+	if issues[0].Fix == nil || issues[0].Fix.Edits[0].NewText != " string" {
+		t.Errorf("Expected a fix adding the string type, got %+v (%s)", issues[0].Fix, issues[0].NoFixReason)
+	}
+}
+
+func TestLintFixesAreDeterministic(t *testing.T) {
+	var filename string
+	filename = writeTestFile(t, `package main
+
+func f() int { return 1 }
+
+func main() {
+	for _, v := range []int{1} {
+		println(v)
+	}
+	if v := f(); v > 0 {
+		println(v)
+	}
+}
+`)
+
+	var linter *Linter
+	linter = New()
+	linter.SetFixes(true)
+
+	var first []byte
+	var i int
+	for i = 0; i < 20; i++ {
+		var results []FixResult
+		var err error
+		results, err = linter.ApplyFixes(linter.Lint([]string{filename}))
+		if err != nil {
+			t.Fatalf("Failed to apply fixes: %v", err)
+		}
+		if len(results) != 1 {
+			t.Fatalf("Expected 1 file with fixes, got %d", len(results))
+		}
+		if first == nil {
+			first = results[0].After
+		} else if string(results[0].After) != string(first) {
+			t.Fatalf("Fixes differ between runs:\n%s\n---\n%s", first, results[0].After)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package linter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/thierry-f-78/go-syntax/pkg/types"
+)
+
+// guard calls check, a run of the rule named rule on filename. A panic
+// becomes an internal-error issue naming the rule.
+func guard(filename string, rule string, check func() []types.Issue) []types.Issue {
+	var issues []types.Issue
+	var recovered interface{}
+	func() {
+		defer func() {
+			recovered = recover()
+		}()
+		issues = check()
+	}()
+	if recovered != nil {
+		return []types.Issue{internalError(filename, 1, rule, recovered)}
+	}
+	return issues
+}
+
+// internalError reports that the rule named rule panicked with recovered
+// on the line of filename. rule is empty when the panic came from the
+// linter itself.
+func internalError(filename string, line int, rule string, recovered interface{}) types.Issue {
+	var message string
+	message = fmt.Sprintf("Internal error: %v", recovered)
+	if rule != "" {
+		message = fmt.Sprintf("Internal error: rule %s panicked: %v", rule, recovered)
+	}
+	return types.Issue{
+		File:        filename,
+		Line:        line,
+		Column:      1,
+		Message:     message,
+		Description: "This is a bug of go-syntax or of the rule, the file is not fully checked.",
+		Rule:        "internal-error",
+	}
+}
+
+// timeoutIssue reports that linting filename took more than timeout.
+func timeoutIssue(filename string, timeout time.Duration) types.Issue {
+	return types.Issue{
+		File:        filename,
+		Line:        1,
+		Column:      1,
+		Message:     fmt.Sprintf("Timeout: linting took more than %s", timeout),
+		Description: "The file is not checked.",
+		Rule:        "timeout",
+	}
+}
+
+// run calls f, waiting for it until ctx is done or timeout, if not zero,
+// has elapsed. It returns false when it gave up: f keeps running in the
+// background, and must not change anything its caller uses afterwards.
+func run(ctx context.Context, timeout time.Duration, f func()) bool {
+	if timeout == 0 && ctx.Done() == nil {
+		f()
+		return true
+	}
+
+	var done chan struct{}
+	done = make(chan struct{})
+	go func() {
+		defer close(done)
+		f()
+	}()
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		var timer *time.Timer
+		timer = time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-expired:
+		return false
+	}
+}
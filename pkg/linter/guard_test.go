@@ -0,0 +1,150 @@
+package linter
+
+import (
+	"context"
+	"go/ast"
+	"go/token"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thierry-f-78/go-syntax/pkg/rules"
+	"github.com/thierry-f-78/go-syntax/pkg/types"
+)
+
+// panicRule is a rule panicking on every file.
+type panicRule struct{}
+
+func (r *panicRule) Name() string {
+	return "test-panic"
+}
+
+func (r *panicRule) Check(fset *token.FileSet, file *ast.File) []types.Issue {
+	var m map[string]int
+	m["boom"]++
+	return nil
+}
+
+// panicNodeRule is a node rule panicking on the functions.
+type panicNodeRule struct{}
+
+func (r *panicNodeRule) Name() string {
+	return "test-panic-node"
+}
+
+func (r *panicNodeRule) Check(fset *token.FileSet, file *ast.File) []types.Issue {
+	return nil
+}
+
+func (r *panicNodeRule) NodeTypes() []ast.Node {
+	return []ast.Node{(*ast.FuncDecl)(nil)}
+}
+
+func (r *panicNodeRule) Visit(pass *types.Pass, node ast.Node, stack []ast.Node) []types.Issue {
+	panic("visiting " + node.(*ast.FuncDecl).Name.Name)
+}
+
+// blockingRule is a rule blocking until release is closed.
+type blockingRule struct {
+	release chan struct{}
+}
+
+func (r *blockingRule) Name() string {
+	return "test-blocking"
+}
+
+func (r *blockingRule) Check(fset *token.FileSet, file *ast.File) []types.Issue {
+	<-r.release
+	return nil
+}
+
+func TestLintRecoversPanics(t *testing.T) {
+	var filename string
+	filename = writeTestFile(t, `package main
+
+func main() {
+	x := 1
+	println(x)
+}
+`)
+
+	var linter *Linter
+	linter = &Linter{rules: []types.Rule{&panicRule{}, &panicNodeRule{}, &rules.ShortVarDeclRule{}}}
+
+	var issues []types.Issue
+	issues = linter.Lint([]string{filename})
+	var messages []string
+	var issue types.Issue
+	for _, issue = range issues {
+		messages = append(messages, issue.Rule+": "+issue.Message)
+	}
+	var got string
+	got = strings.Join(messages, "\n")
+	var expected string
+	expected = "internal-error: Internal error: rule test-panic panicked: assignment to entry in nil map\n" +
+		"internal-error: Internal error: rule test-panic-node panicked: visiting main\n" +
+		"short-var-decl: Short variable declaration ':=' is not allowed"
+	if got != expected {
+		t.Errorf("Expected issues:\n%s\ngot:\n%s", expected, got)
+	}
+	if issues[1].Line != 3 {
+		t.Errorf("Expected the panic of the node rule on line 3, got %d", issues[1].Line)
+	}
+}
+
+func TestLintFileTimeout(t *testing.T) {
+	var slow string
+	slow = writeTestFile(t, "package main\n")
+
+	var rule *blockingRule
+	rule = &blockingRule{release: make(chan struct{})}
+	defer close(rule.release)
+
+	var linter *Linter
+	linter = &Linter{rules: []types.Rule{rule}}
+	linter.SetFileTimeout(50 * time.Millisecond)
+
+	var issues []types.Issue
+	var err error
+	issues, err = linter.LintContext(context.Background(), []string{slow})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(issues) != 1 || issues[0].Rule != "timeout" {
+		t.Errorf("Expected a timeout issue, got %v", issues)
+	}
+}
+
+func TestLintContextCancel(t *testing.T) {
+	var filename string
+	filename = writeTestFile(t, "package main\n")
+
+	var rule *blockingRule
+	rule = &blockingRule{release: make(chan struct{})}
+	defer close(rule.release)
+
+	var linter *Linter
+	linter = &Linter{rules: []types.Rule{rule}}
+
+	var ctx context.Context
+	var cancel context.CancelFunc
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	var done chan error
+	done = make(chan error, 1)
+	go func() {
+		var err error
+		_, err = linter.LintContext(ctx, []string{filename, filename})
+		done <- err
+	}()
+	var err error
+	select {
+	case err = <-done:
+		if err != context.DeadlineExceeded {
+			t.Errorf("Expected %v, got %v", context.DeadlineExceeded, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("LintContext did not stop on cancellation")
+	}
+}
@@ -1,54 +1,563 @@
 package linter
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"go/ast"
+	"go/build"
 	"go/parser"
 	"go/token"
+	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/thierry-f-78/go-syntax/pkg/cache"
+	"github.com/thierry-f-78/go-syntax/pkg/config"
+	"github.com/thierry-f-78/go-syntax/pkg/loader"
 	"github.com/thierry-f-78/go-syntax/pkg/rules"
 	"github.com/thierry-f-78/go-syntax/pkg/types"
 )
 
 type Linter struct {
 	rules []types.Rule
+	fixes bool
+	// suppressed keeps the issues silenced by //nolint comments.
+	suppressed bool
+	// workers is the number of files linted in parallel.
+	workers int
+	// fileTimeout is the time given to each file, if not zero.
+	fileTimeout time.Duration
+	// cache, when set, keeps the issues of the files between runs.
+	cache *cache.Cache
+	// ctxt selects the files of the packages type-checked by the typed
+	// rules and the fixes.
+	ctxt *build.Context
+	// overlay is the content of the files read instead of the disk, by
+	// absolute path, as set by SetOverlay.
+	overlay map[string][]byte
+
+	// resolver, when set, gives the configuration of each directory,
+	// whose rules are kept in byConfig.
+	resolver *config.Resolver
+	byConfig map[*config.Config][]types.Rule
+
+	// importers are the idle importers of the packages imported by those
+	// type-checked for the typed rules, generation the number of changes
+	// of their build context. typedMu guards them.
+	typedMu    sync.Mutex
+	importers  []*loader.Importer
+	generation int
+}
+
+// Options selects and configures the rules of NewWithOptions.
+type Options struct {
+	// Rules are the names of the registered rules to run. When empty, the
+	// rules enabled by default run.
+	Rules []string
+	// RuleOptions are the options of the rules, by rule name then option
+	// name.
+	RuleOptions map[string]map[string]interface{}
 }
 
+// New returns a linter running the registered rules enabled by default.
 func New() *Linter {
 	return &Linter{
-		rules: []types.Rule{
-			&rules.ShortVarDeclRule{},
-			&rules.VarNoTypeRule{},
-			&rules.ConstNoTypeRule{},
-			&rules.NamedReturnsRule{},
-			&rules.NakedReturnRule{},
-			&rules.IfInitRule{},
-		},
+		rules: defaultRules(),
+	}
+}
+
+func defaultRules() []types.Rule {
+	var defaults []types.Rule
+	var info rules.Info
+	for _, info = range rules.All() {
+		if info.Enabled {
+			defaults = append(defaults, info.New())
+		}
+	}
+	return defaults
+}
+
+// NewWithOptions returns a linter running the registered rules selected
+// by opts.
+func NewWithOptions(opts Options) (*Linter, error) {
+	var names []string = opts.Rules
+	if len(names) == 0 {
+		var info rules.Info
+		for _, info = range rules.All() {
+			if info.Enabled {
+				names = append(names, info.Name)
+			}
+		}
+	}
+
+	var name string
+	for name = range opts.RuleOptions {
+		if !contains(names, name) {
+			return nil, fmt.Errorf("options given for rule %q, which is not run", name)
+		}
+	}
+
+	var selected []types.Rule
+	for _, name = range names {
+		var info rules.Info
+		var ok bool
+		info, ok = rules.Lookup(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown rule %q", name)
+		}
+
+		var rule types.Rule
+		rule = info.New()
+		var optionNames []string
+		var option string
+		for option = range opts.RuleOptions[name] {
+			optionNames = append(optionNames, option)
+		}
+		sort.Strings(optionNames)
+		for _, option = range optionNames {
+			var err error
+			err = setOption(rule, info, option, opts.RuleOptions[name][option])
+			if err != nil {
+				return nil, err
+			}
+		}
+		selected = append(selected, rule)
+	}
+	return &Linter{rules: selected}, nil
+}
+
+func contains(list []string, s string) bool {
+	var item string
+	for _, item = range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// setOption sets the option name of rule, checking it against the options
+// info declares.
+func setOption(rule types.Rule, info rules.Info, name string, value interface{}) error {
+	var configurable types.ConfigurableRule
+	var ok bool
+	configurable, ok = rule.(types.ConfigurableRule)
+	if !ok || len(info.Options) == 0 {
+		return fmt.Errorf("rule %q has no options", info.Name)
+	}
+
+	var known bool
+	var optionInfo rules.OptionInfo
+	for _, optionInfo = range info.Options {
+		known = known || optionInfo.Name == name
+	}
+	if !known {
+		return fmt.Errorf("rule %q: unknown option %q", info.Name, name)
+	}
+
+	var err error
+	err = configurable.SetOption(name, value)
+	if err != nil {
+		return fmt.Errorf("rule %q: %v", info.Name, err)
+	}
+	return nil
+}
+
+// NewFromConfig returns a linter running the registered rules, enabled,
+// disabled and given options as cfg says. Unknown rules and options are
+// reported with their location in the configuration file.
+func NewFromConfig(cfg *config.Config) (*Linter, error) {
+	var configured []types.Rule
+	var err error
+	configured, err = configuredRules(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Linter{rules: configured}, nil
+}
+
+// NewWithResolver returns a linter running, on each file, the rules of
+// the effective configuration of its directory. Errors in the
+// configuration files are reported as issues of the "config" rule, and
+// the files they apply to are not analyzed.
+func NewWithResolver(resolver *config.Resolver) *Linter {
+	return &Linter{
+		resolver: resolver,
+		byConfig: make(map[*config.Config][]types.Rule),
+	}
+}
+
+// configuredRules returns the registered rules configured by cfg.
+func configuredRules(cfg *config.Config) ([]types.Rule, error) {
+	var all []rules.Info
+	all = rules.All()
+
+	var byName map[string]types.Rule
+	byName = make(map[string]types.Rule)
+	var enabled map[string]bool
+	enabled = make(map[string]bool)
+	var info rules.Info
+	for _, info = range all {
+		byName[info.Name] = info.New()
+		enabled[info.Name] = info.Enabled
+	}
+
+	var ruleConfig config.RuleConfig
+	for _, ruleConfig = range cfg.Rules {
+		var rule types.Rule
+		rule = byName[ruleConfig.Name]
+		if rule == nil {
+			return nil, configError(ruleConfig.Origin, "unknown rule %q", ruleConfig.Name)
+		}
+		if ruleConfig.Enabled != nil {
+			enabled[ruleConfig.Name] = ruleConfig.Enabled.Value
+		}
+
+		info, _ = rules.Lookup(ruleConfig.Name)
+		var option config.Option
+		for _, option = range ruleConfig.Options {
+			var err error
+			err = setOption(rule, info, option.Name, option.Value)
+			if err != nil {
+				return nil, configError(option.Origin, "%v", err)
+			}
+		}
+	}
+
+	var configured []types.Rule
+	for _, info = range all {
+		if enabled[info.Name] {
+			configured = append(configured, byName[info.Name])
+		}
+	}
+	return configured, nil
+}
+
+func configError(origin config.Origin, format string, args ...interface{}) error {
+	return &config.Error{File: origin.File, Line: origin.Line, Message: fmt.Sprintf(format, args...)}
+}
+
+// Rules returns the rules run by l. A linter made by NewWithResolver has
+// none of its own.
+func (l *Linter) Rules() []types.Rule {
+	return l.rules
+}
+
+// rulesFor returns the rules to run on filename.
+func (l *Linter) rulesFor(filename string) ([]types.Rule, error) {
+	if l.resolver == nil {
+		return l.rules, nil
+	}
+
+	var cfg *config.Config
+	var err error
+	cfg, err = l.resolver.Resolve(filepath.Dir(filename))
+	if err != nil {
+		return nil, err
+	}
+
+	var configured []types.Rule
+	var ok bool
+	configured, ok = l.byConfig[cfg]
+	if ok {
+		return configured, nil
+	}
+	configured, err = configuredRules(cfg)
+	if err != nil {
+		return nil, err
 	}
+	l.byConfig[cfg] = configured
+	return configured, nil
+}
+
+// SetBuildContext sets the build context selecting the files of the
+// packages the typed rules and the fixes type-check, build.Default by
+// default.
+func (l *Linter) SetBuildContext(ctxt *build.Context) {
+	l.typedMu.Lock()
+	defer l.typedMu.Unlock()
+	l.ctxt = ctxt
+	l.resetImporters()
 }
 
+// SetWorkers sets the number of files linted in parallel, GOMAXPROCS when
+// zero, the default.
+func (l *Linter) SetWorkers(workers int) {
+	l.workers = workers
+}
+
+// SetFileTimeout sets the time given to the linting of each file, and to
+// the package rules of each package. A file taking longer gets a timeout
+// issue instead of its issues. Zero, the default, sets no limit.
+func (l *Linter) SetFileTimeout(timeout time.Duration) {
+	l.fileTimeout = timeout
+}
+
+// SetFixes makes Lint attach their suggested fix to the issues. Computing
+// the fixes type-checks the packages of the files.
+func (l *Linter) SetFixes(enabled bool) {
+	l.fixes = enabled
+}
+
+// SetSuppressed makes Lint return the issues silenced by a //nolint
+// comment too, marked Suppressed, instead of dropping them.
+func (l *Linter) SetSuppressed(enabled bool) {
+	l.suppressed = enabled
+}
+
+// Lint lints files on up to the workers set by SetWorkers. The issues
+// come in the order of files whatever the scheduling. With a cache, the
+// files unchanged since a previous run are not linted again.
 func (l *Linter) Lint(files []string) []types.Issue {
-	var allIssues []types.Issue
+	var issues []types.Issue
+	issues, _ = l.LintContext(context.Background(), files)
+	return issues
+}
+
+// LintContext is Lint, stopping when ctx is done: it then returns the
+// error of ctx, without waiting for the rules running.
+func (l *Linter) LintContext(ctx context.Context, files []string) ([]types.Issue, error) {
+	return l.lint(ctx, files, l.overlay)
+}
+
+// lint is LintContext reading the files of overlay instead of the disk.
+func (l *Linter) lint(ctx context.Context, files []string, overlay map[string][]byte) ([]types.Issue, error) {
+	// The packages are type-checked anew: the files may have changed
+	// since the last run, fixed for instance.
+	var r *lintRun
+	r = &lintRun{overlay: overlay}
 
+	// The rules of each file, and the file sets shared by the files of
+	// a directory parsed without types, are settled beforehand.
+	var results []fileResult
+	results = make([]fileResult, len(files))
+	var fsets map[string]*token.FileSet
+	fsets = make(map[string]*token.FileSet)
+	var reported map[string]bool
+	reported = make(map[string]bool)
+	var i int
 	var file string
-	for _, file = range files {
+	for i, file = range files {
+		var err error
+		results[i].rules, err = l.rulesFor(file)
+		if err != nil {
+			results[i].failed = true
+			// Report each configuration error once.
+			if !reported[err.Error()] {
+				reported[err.Error()] = true
+				results[i].issues = []types.Issue{configIssue(file, err)}
+			}
+			continue
+		}
+		if fsets[filepath.Dir(file)] == nil {
+			fsets[filepath.Dir(file)] = token.NewFileSet()
+		}
+	}
+
+	if l.cache != nil {
+		l.lookup(ctx, r, files, results)
+	}
+
+	parallel(ctx, l.workers, len(files), func(i int) {
+		if results[i].failed || results[i].cached {
+			return
+		}
+		// A file given up on keeps being linted in the background: the
+		// results go to variables of their own.
+		var fset *token.FileSet
+		var src *ast.File
 		var issues []types.Issue
-		issues = l.lintFile(file)
-		allIssues = append(allIssues, issues...)
+		if !run(ctx, l.fileTimeout, func() {
+			fset, src, issues = l.guardedLintFile(r, files[i], results[i].rules, fsets)
+		}) {
+			results[i].issues = []types.Issue{timeoutIssue(files[i], l.fileTimeout)}
+			results[i].transient = true
+			return
+		}
+		results[i].fset, results[i].src, results[i].issues = fset, src, issues
+	})
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	// The files are grouped by package for the package rules.
+	var packages map[packageKey]*filePackage
+	packages = make(map[packageKey]*filePackage)
+	var order []*filePackage
+	for i, file = range files {
+		if results[i].src == nil {
+			continue
+		}
+
+		var key packageKey
+		key = packageKey{dir: filepath.Dir(file), name: results[i].src.Name.Name, fset: results[i].fset}
+		var pkg *filePackage
+		pkg = packages[key]
+		if pkg == nil {
+			pkg = &filePackage{rules: results[i].rules, fset: results[i].fset}
+			packages[key] = pkg
+			order = append(order, pkg)
+		}
+		pkg.files = append(pkg.files, results[i].src)
+		pkg.results = append(pkg.results, &results[i])
+	}
+	parallel(ctx, l.workers, len(order), func(i int) {
+		var issues [][]types.Issue
+		if !run(ctx, l.fileTimeout, func() {
+			issues = order[i].lint()
+		}) {
+			order[i].results[0].issues = append(order[i].results[0].issues, timeoutIssue(order[i].fset.File(order[i].files[0].Pos()).Name(), l.fileTimeout))
+			order[i].results[0].transient = true
+			return
+		}
+		var j int
+		for j = range issues {
+			order[i].results[j].issues = append(order[i].results[j].issues, issues[j]...)
+		}
+	})
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if l.cache != nil {
+		l.store(ctx, results)
+	}
+
+	var allIssues []types.Issue
+	for i = range files {
+		var issue types.Issue
+		for _, issue = range results[i].issues {
+			if !issue.Suppressed || l.suppressed {
+				allIssues = append(allIssues, issue)
+			}
+		}
+	}
+
+	if l.fixes {
+		attachFixes(l.buildContext(overlay), allIssues, files)
 	}
 
-	return allIssues
+	return allIssues, ctx.Err()
 }
 
-func (l *Linter) lintFile(filename string) []types.Issue {
-	var fset *token.FileSet
-	fset = token.NewFileSet()
+// fileResult is the outcome of the linting of a file.
+type fileResult struct {
+	// failed tells the rules of the file are unknown: its configuration
+	// is invalid.
+	failed bool
+	rules  []types.Rule
+	fset   *token.FileSet
+	src    *ast.File
+	// issues are those of the file rules, then of the package rules.
+	issues []types.Issue
+
+	// key is the cache key of the file, if any, and cached tells its
+	// issues come from the cache. transient issues, such as timeouts,
+	// are not cached.
+	key       *cache.Key
+	cached    bool
+	transient bool
+}
 
+// parallel calls f with each index below n, on up to workers goroutines.
+// It stops calling f once ctx is done.
+func parallel(ctx context.Context, workers int, n int, f func(i int)) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > n {
+		workers = n
+	}
+
+	var indexes chan int
+	indexes = make(chan int)
+	var wg sync.WaitGroup
+	var w int
+	for w = 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var i int
+			for i = range indexes {
+				f(i)
+			}
+		}()
+	}
+	var i int
+dispatch:
+	for i = 0; i < n; i++ {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+// LintPackages lints the files of pkgs, as loaded by pkg/loader.
+func (l *Linter) LintPackages(pkgs []*loader.Package) []types.Issue {
+	var files []string
+	var pkg *loader.Package
+	for _, pkg = range pkgs {
+		files = append(files, pkg.Files()...)
+	}
+	return l.Lint(files)
+}
+
+// configIssue reports the error err met resolving the configuration of
+// filename as an issue, located in the configuration file when possible.
+func configIssue(filename string, err error) types.Issue {
+	var issue types.Issue
+	issue = types.Issue{
+		File:    filename,
+		Line:    1,
+		Column:  1,
+		Message: "Configuration error: " + err.Error(),
+		Rule:    "config",
+	}
+
+	var cfgErr *config.Error
+	if errors.As(err, &cfgErr) {
+		issue.File = cfgErr.File
+		issue.Line = cfgErr.Line
+		issue.Message = "Configuration error: " + cfgErr.Message
+	}
+	return issue
+}
+
+// lintFile runs the file rules of fileRules on filename, and returns it
+// along with its file set. The files parsed without types go to the file
+// set of their directory in fsets, which it does not change.
+func (l *Linter) lintFile(r *lintRun, filename string, fileRules []types.Rule, fsets map[string]*token.FileSet) (*token.FileSet, *ast.File, []types.Issue) {
+	var typed *typedPackage
+	if hasTypedRule(fileRules) {
+		typed = l.typeCheck(r, filename)
+	}
+
+	var fset *token.FileSet
 	var src *ast.File
 	var err error
-	src, err = parser.ParseFile(fset, filename, nil, parser.ParseComments)
+	if typed != nil {
+		fset = typed.fset
+		src = typed.files[filepath.Clean(filename)]
+	}
+	if src == nil {
+		typed = nil
+		fset = fsets[filepath.Dir(filename)]
+		var data []byte
+		data, err = r.readFile(filename)
+		if err == nil {
+			src, err = parser.ParseFile(fset, filename, data, parser.ParseComments)
+		}
+	}
 	if err != nil {
-		return []types.Issue{{
+		return nil, nil, []types.Issue{{
 			File:    filename,
 			Line:    1,
 			Column:  1,
@@ -57,24 +566,132 @@ func (l *Linter) lintFile(filename string) []types.Issue {
 		}}
 	}
 
+	// The node rules share a single walk of the file.
+	var nodeRules []types.NodeRule
+	var rule types.Rule
+	for _, rule = range fileRules {
+		var nodeRule types.NodeRule
+		var ok bool
+		nodeRule, ok = rule.(types.NodeRule)
+		if ok {
+			nodeRules = append(nodeRules, nodeRule)
+		}
+	}
+	var pass *types.Pass
+	pass = &types.Pass{Fset: fset, File: src}
+	if typed != nil {
+		pass.Pkg = typed.pkg
+		pass.Info = typed.info
+	}
+	var visited [][]types.Issue
+	visited = Walk(pass, nodeRules)
+
 	var issues []types.Issue
+	for _, rule = range fileRules {
+		var ok bool
+		_, ok = rule.(types.PackageRule)
+		if ok {
+			continue
+		}
+		_, ok = rule.(types.NodeRule)
+		if ok {
+			issues = append(issues, visited[0]...)
+			visited = visited[1:]
+			continue
+		}
 
-	var rule types.Rule
-	for _, rule = range l.rules {
-		var ruleIssues []types.Issue
-		ruleIssues = rule.Check(fset, src)
-		issues = append(issues, ruleIssues...)
+		issues = append(issues, guard(filename, rule.Name(), func() []types.Issue {
+			var typedRule types.TypedRule
+			var ok bool
+			typedRule, ok = rule.(types.TypedRule)
+			if ok && typed != nil {
+				return typedRule.CheckTyped(fset, src, typed.pkg, typed.info)
+			}
+			return rule.Check(fset, src)
+		})...)
 	}
 
-	return filterNolintIssues(issues, src, fset)
+	return fset, src, MarkNolint(issues, src, fset)
 }
 
-func filterNolintIssues(issues []types.Issue, file *ast.File, fset *token.FileSet) []types.Issue {
+// guardedLintFile is lintFile, turning its panics out of the rules into
+// an internal-error issue.
+func (l *Linter) guardedLintFile(r *lintRun, filename string, fileRules []types.Rule, fsets map[string]*token.FileSet) (*token.FileSet, *ast.File, []types.Issue) {
+	var fset *token.FileSet
+	var src *ast.File
+	var issues []types.Issue
+	issues = guard(filename, "", func() []types.Issue {
+		var issues []types.Issue
+		fset, src, issues = l.lintFile(r, filename, fileRules, fsets)
+		return issues
+	})
+	return fset, src, issues
+}
+
+// packageKey identifies the package of a linted file. The files of a
+// package parsed with different file sets, such as a file excluded from
+// the type-checked package, are checked apart.
+type packageKey struct {
+	dir  string
+	name string
+	fset *token.FileSet
+}
+
+// filePackage is the linted files of a package, checked together by the
+// package rules.
+type filePackage struct {
+	rules   []types.Rule
+	fset    *token.FileSet
+	files   []*ast.File
+	results []*fileResult
+}
+
+// lint runs the package rules of p once, and returns their issues by
+// file of p, marked with its //nolint comments. The issues out of the
+// files of p go to the first one.
+func (p *filePackage) lint() [][]types.Issue {
+	var byName map[string]int
+	byName = make(map[string]int)
+	var i int
+	var file *ast.File
+	for i, file = range p.files {
+		byName[p.fset.File(file.Pos()).Name()] = i
+	}
+
+	var issues [][]types.Issue
+	issues = make([][]types.Issue, len(p.files))
+	var rule types.Rule
+	for _, rule = range p.rules {
+		var packageRule types.PackageRule
+		var ok bool
+		packageRule, ok = rule.(types.PackageRule)
+		if !ok {
+			continue
+		}
+
+		var issue types.Issue
+		for _, issue = range guard(p.fset.File(p.files[0].Pos()).Name(), rule.Name(), func() []types.Issue {
+			return packageRule.CheckPackage(p.fset, p.files)
+		}) {
+			i, ok = byName[issue.File]
+			if !ok {
+				issues[0] = append(issues[0], issue)
+				continue
+			}
+			issues[i] = append(issues[i], MarkNolint([]types.Issue{issue}, p.files[i], p.fset)...)
+		}
+	}
+	return issues
+}
+
+// FilterNolint returns the issues of file not silenced by a //nolint
+// comment.
+func FilterNolint(issues []types.Issue, file *ast.File, fset *token.FileSet) []types.Issue {
 	var filtered []types.Issue
 
 	var issue types.Issue
-	for _, issue = range issues {
-		if !isNolintComment(file, issue.Line, issue.Rule, fset) && !isFileNolintComment(file, issue.Rule) {
+	for _, issue = range MarkNolint(issues, file, fset) {
+		if !issue.Suppressed {
 			filtered = append(filtered, issue)
 		}
 	}
@@ -82,6 +699,22 @@ func filterNolintIssues(issues []types.Issue, file *ast.File, fset *token.FileSe
 	return filtered
 }
 
+// MarkNolint returns issues, with those of file silenced by a //nolint
+// comment marked Suppressed.
+func MarkNolint(issues []types.Issue, file *ast.File, fset *token.FileSet) []types.Issue {
+	var marked []types.Issue
+
+	var issue types.Issue
+	for _, issue = range issues {
+		if isNolintComment(file, issue.Line, issue.Rule, fset) || isFileNolintComment(file, issue.Rule) {
+			issue.Suppressed = true
+		}
+		marked = append(marked, issue)
+	}
+
+	return marked
+}
+
 func isNolintComment(file *ast.File, line int, ruleName string, fset *token.FileSet) bool {
 	var commentGroup *ast.CommentGroup
 	for _, commentGroup = range file.Comments {
@@ -1,11 +1,18 @@
 package linter
 
 import (
+	"fmt"
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/thierry-f-78/go-syntax/pkg/config"
+	"github.com/thierry-f-78/go-syntax/pkg/rules"
 	"github.com/thierry-f-78/go-syntax/pkg/types"
 )
 
@@ -100,7 +107,7 @@ func someFunc() error { return nil }`,
 
 			// Apply nolint filtering
 			var filteredIssues []types.Issue
-			filteredIssues = filterNolintIssues(allIssues, file, fset)
+			filteredIssues = FilterNolint(allIssues, file, fset)
 
 			if len(filteredIssues) != tt.expected {
 				t.Errorf("Expected %d issues after nolint filtering, got %d", tt.expected, len(filteredIssues))
@@ -119,6 +126,37 @@ func someFunc() error { return nil }`,
 	}
 }
 
+func TestLintSuppressed(t *testing.T) {
+	var filename string
+	filename = writeTestFile(t, `package main
+
+func main() {
+	x := 42 //nolint:short-var-decl
+	y := "test"
+	_, _ = x, y
+}
+`)
+
+	var linter *Linter
+	var err error
+	linter, err = NewWithOptions(Options{Rules: []string{"short-var-decl"}})
+	if err != nil {
+		t.Fatalf("Failed to create linter: %v", err)
+	}
+
+	var issues []types.Issue
+	issues = linter.Lint([]string{filename})
+	if len(issues) != 1 || issues[0].Line != 5 {
+		t.Errorf("Expected an issue at line 5, got %v", issues)
+	}
+
+	linter.SetSuppressed(true)
+	issues = linter.Lint([]string{filename})
+	if len(issues) != 2 || !issues[0].Suppressed || issues[0].Line != 4 || issues[1].Suppressed {
+		t.Errorf("Expected the issue at line 4 suppressed, then the one at line 5, got %v", issues)
+	}
+}
+
 func TestIsNolintComment(t *testing.T) {
 	var tests []struct {
 		name     string
@@ -346,7 +384,7 @@ func main() {
 
 			// Apply nolint filtering (both line-level and file-level)
 			var filteredIssues []types.Issue
-			filteredIssues = filterNolintIssues(allIssues, file, fset)
+			filteredIssues = FilterNolint(allIssues, file, fset)
 
 			if len(filteredIssues) != tt.expected {
 				t.Errorf("Expected %d issues after file-level nolint filtering, got %d", tt.expected, len(filteredIssues))
@@ -364,3 +402,557 @@ func main() {
 		})
 	}
 }
+
+func TestNewFromConfig(t *testing.T) {
+	var cfg *config.Config
+	var err error
+	cfg, err = config.Parse("cfg.yml", []byte(`rules:
+  named-returns: false
+  naked-return:
+    max-func-lines: 3
+`))
+	if err != nil {
+		t.Fatalf("Failed to parse config: %v", err)
+	}
+
+	var linter *Linter
+	linter, err = NewFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create linter: %v", err)
+	}
+
+	var rule types.Rule
+	for _, rule = range linter.rules {
+		if rule.Name() == "named-returns" {
+			t.Errorf("Expected named-returns to be disabled")
+		}
+		var naked *rules.NakedReturnRule
+		var ok bool
+		naked, ok = rule.(*rules.NakedReturnRule)
+		if ok && naked.MaxFuncLines != 3 {
+			t.Errorf("Expected max-func-lines 3, got %d", naked.MaxFuncLines)
+		}
+	}
+	if len(linter.rules) != 5 {
+		t.Errorf("Expected 5 rules, got %d", len(linter.rules))
+	}
+}
+
+func TestNewFromConfigErrors(t *testing.T) {
+	var tests []struct {
+		name     string
+		data     string
+		expected string
+	}
+	tests = []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{
+			name:     "unknown_rule",
+			data:     "rules:\n  if-init: true\n  no-goto: true\n",
+			expected: `cfg.yml:3: unknown rule "no-goto"`,
+		},
+		{
+			name:     "unknown_option",
+			data:     "rules:\n  if-init:\n    enabled: true\n    allow-all: true\n",
+			expected: `cfg.yml:4: rule "if-init": unknown option "allow-all"`,
+		},
+		{
+			name:     "rule_without_options",
+			data:     "rules:\n  var-no-type:\n    strict: true\n",
+			expected: `cfg.yml:3: rule "var-no-type" has no options`,
+		},
+	}
+
+	var tt struct {
+		name     string
+		data     string
+		expected string
+	}
+	for _, tt = range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg *config.Config
+			var err error
+			cfg, err = config.Parse("cfg.yml", []byte(tt.data))
+			if err != nil {
+				t.Fatalf("Failed to parse config: %v", err)
+			}
+
+			_, err = NewFromConfig(cfg)
+			if err == nil || err.Error() != tt.expected {
+				t.Errorf("Expected error %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestLintPerDirectoryConfig(t *testing.T) {
+	var root string
+	root = t.TempDir()
+
+	var files map[string]string
+	files = map[string]string{
+		config.FileName:                     "rules:\n  named-returns: false\n",
+		"a.go":                              "package a\n\nfunc f() (n int) {\n\treturn n\n}\n",
+		"legacy/" + config.FileName:         "rules:\n  named-returns: true\n",
+		"legacy/b.go":                       "package b\n\nfunc f() (n int) {\n\treturn n\n}\n",
+		"broken/" + config.FileName:         "rules:\n  no-goto: false\n",
+		"broken/c.go":                       "package c\n",
+		"standalone/" + config.FileName:     "root: true\n",
+		"standalone/d.go":                   "package d\n\nfunc f() (n int) {\n\treturn n\n}\n",
+		"standalone/sub/" + config.FileName: "rules:\n  short-var-decl: false\n",
+		"standalone/sub/e.go":               "package e\n\nfunc f() (n int) {\n\treturn n\n}\n",
+	}
+	var name string
+	var code string
+	for name, code = range files {
+		var err error
+		err = os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0o755)
+		if err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		err = os.WriteFile(filepath.Join(root, name), []byte(code), 0o644)
+		if err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	var linter *Linter
+	linter = NewWithResolver(&config.Resolver{})
+	var issues []types.Issue
+	issues = linter.Lint([]string{
+		filepath.Join(root, "a.go"),
+		filepath.Join(root, "legacy", "b.go"),
+		filepath.Join(root, "broken", "c.go"),
+		filepath.Join(root, "standalone", "d.go"),
+		filepath.Join(root, "standalone", "sub", "e.go"),
+	})
+
+	var byFile map[string][]string
+	byFile = make(map[string][]string)
+	var issue types.Issue
+	for _, issue = range issues {
+		var rel string
+		rel, _ = filepath.Rel(root, issue.File)
+		byFile[filepath.ToSlash(rel)] = append(byFile[filepath.ToSlash(rel)], issue.Rule)
+	}
+
+	var expected map[string][]string
+	expected = map[string][]string{
+		"legacy/b.go":               {"named-returns"},
+		"broken/" + config.FileName: {"config"},
+		"standalone/d.go":           {"named-returns"},
+		"standalone/sub/e.go":       {"named-returns"},
+	}
+	if len(byFile) != len(expected) {
+		t.Errorf("Expected issues in %d files, got %v", len(expected), byFile)
+	}
+	var want []string
+	for name, want = range expected {
+		if strings.Join(byFile[name], ",") != strings.Join(want, ",") {
+			t.Errorf("Expected %v in %s, got %v", want, name, byFile[name])
+		}
+	}
+}
+
+// noGotoRule is a rule registered by the tests, as another module would.
+type noGotoRule struct{}
+
+func (r *noGotoRule) Name() string {
+	return "test-no-goto"
+}
+
+func (r *noGotoRule) Check(fset *token.FileSet, file *ast.File) []types.Issue {
+	var issues []types.Issue
+	ast.Inspect(file, func(n ast.Node) bool {
+		var branch *ast.BranchStmt
+		var ok bool
+		branch, ok = n.(*ast.BranchStmt)
+		if ok && branch.Tok == token.GOTO {
+			var pos token.Position
+			pos = fset.Position(branch.Pos())
+			issues = append(issues, types.Issue{File: pos.Filename, Line: pos.Line, Column: pos.Column, Message: "goto", Rule: r.Name()})
+		}
+		return true
+	})
+	return issues
+}
+
+func init() {
+	rules.Register(rules.Info{
+		Name:        "test-no-goto",
+		Description: "Goto statements",
+		Category:    "control-flow",
+		New:         func() types.Rule { return &noGotoRule{} },
+	})
+}
+
+func TestNewWithOptions(t *testing.T) {
+	var filename string
+	filename = writeTestFile(t, `package main
+
+func f() (n int) {
+	x := 1
+	goto end
+end:
+	return
+}
+`)
+
+	var tests []struct {
+		name     string
+		options  Options
+		expected []string
+		err      string
+	}
+	tests = []struct {
+		name     string
+		options  Options
+		expected []string
+		err      string
+	}{
+		{
+			name:     "rules enabled by default",
+			options:  Options{},
+			expected: []string{"short-var-decl", "named-returns", "naked-return"},
+		},
+		{
+			name:     "selected rules",
+			options:  Options{Rules: []string{"test-no-goto", "named-returns"}},
+			expected: []string{"test-no-goto", "named-returns"},
+		},
+		{
+			name: "rule options",
+			options: Options{
+				Rules:       []string{"naked-return"},
+				RuleOptions: map[string]map[string]interface{}{"naked-return": {"max-func-lines": 10}},
+			},
+		},
+		{
+			name:    "unknown rule",
+			options: Options{Rules: []string{"no-goto"}},
+			err:     `unknown rule "no-goto"`,
+		},
+		{
+			name: "unknown option",
+			options: Options{
+				RuleOptions: map[string]map[string]interface{}{"if-init": {"allow-all": true}},
+			},
+			err: `rule "if-init": unknown option "allow-all"`,
+		},
+		{
+			name: "options of a rule not run",
+			options: Options{
+				Rules:       []string{"named-returns"},
+				RuleOptions: map[string]map[string]interface{}{"if-init": {"allow-err-check": true}},
+			},
+			err: `options given for rule "if-init", which is not run`,
+		},
+	}
+
+	var tt struct {
+		name     string
+		options  Options
+		expected []string
+		err      string
+	}
+	for _, tt = range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var linter *Linter
+			var err error
+			linter, err = NewWithOptions(tt.options)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("Expected error %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to create linter: %v", err)
+			}
+
+			var found []string
+			var issue types.Issue
+			for _, issue = range linter.Lint([]string{filename}) {
+				found = append(found, issue.Rule)
+			}
+			if strings.Join(found, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("Expected issues %v, got %v", tt.expected, found)
+			}
+		})
+	}
+}
+
+func TestNewFromConfigEnablesRegisteredRules(t *testing.T) {
+	var cfg *config.Config
+	var err error
+	cfg, err = config.Parse("cfg.yml", []byte("rules:\n  test-no-goto: true\n"))
+	if err != nil {
+		t.Fatalf("Failed to parse config: %v", err)
+	}
+
+	var linter *Linter
+	linter, err = NewFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create linter: %v", err)
+	}
+	if len(linter.Rules()) != 7 {
+		t.Errorf("Expected 7 rules, got %d", len(linter.Rules()))
+	}
+}
+
+func TestLintTypedRules(t *testing.T) {
+	var tests []struct {
+		name     string
+		code     string
+		expected int
+	}
+	tests = []struct {
+		name     string
+		code     string
+		expected int
+	}{
+		{
+			name: "var from a variable",
+			code: `package main
+
+var y int = 1
+var x = y
+`,
+			expected: 1,
+		},
+		{
+			name: "var from an imported variable",
+			code: `package main
+
+import "os"
+
+var sep = os.PathSeparator
+`,
+			expected: 1,
+		},
+		{
+			// The package does not type-check: the syntactic check
+			// takes the identifier for a type.
+			name: "fallback to the syntactic check",
+			code: `package main
+
+var x = undefined
+`,
+			expected: 0,
+		},
+	}
+
+	var tt struct {
+		name     string
+		code     string
+		expected int
+	}
+	for _, tt = range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var linter *Linter
+			var err error
+			linter, err = NewWithOptions(Options{Rules: []string{"var-no-type"}})
+			if err != nil {
+				t.Fatalf("Failed to create linter: %v", err)
+			}
+
+			var issues []types.Issue
+			issues = linter.Lint([]string{writeTestFile(t, tt.code)})
+			if len(issues) != tt.expected {
+				t.Errorf("Expected %d issues, got %d: %v", tt.expected, len(issues), issues)
+			}
+		})
+	}
+}
+
+// unusedConstRule is a package rule registered by the tests: it reports
+// the constants used by none of the files of their package.
+type unusedConstRule struct{}
+
+func (r *unusedConstRule) Name() string {
+	return "test-unused-const"
+}
+
+// Check reports nothing: a file alone cannot tell whether the other files
+// of its package use its constants.
+func (r *unusedConstRule) Check(fset *token.FileSet, file *ast.File) []types.Issue {
+	return nil
+}
+
+func (r *unusedConstRule) CheckPackage(fset *token.FileSet, files []*ast.File) []types.Issue {
+	var declared []*ast.Ident
+	var used map[string]bool
+	used = make(map[string]bool)
+	var file *ast.File
+	for _, file = range files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch node := n.(type) {
+			case *ast.GenDecl:
+				if node.Tok != token.CONST {
+					return true
+				}
+				var spec ast.Spec
+				for _, spec = range node.Specs {
+					declared = append(declared, spec.(*ast.ValueSpec).Names...)
+				}
+				return false
+			case *ast.Ident:
+				used[node.Name] = true
+			}
+			return true
+		})
+	}
+
+	var issues []types.Issue
+	var name *ast.Ident
+	for _, name = range declared {
+		if used[name.Name] {
+			continue
+		}
+		var pos token.Position
+		pos = fset.Position(name.Pos())
+		issues = append(issues, types.Issue{
+			File:    pos.Filename,
+			Line:    pos.Line,
+			Column:  pos.Column,
+			Message: "Unused constant " + name.Name,
+			Rule:    r.Name(),
+		})
+	}
+	return issues
+}
+
+func init() {
+	rules.Register(rules.Info{
+		Name:        "test-unused-const",
+		Description: "Unused constants",
+		Category:    "declarations",
+		New:         func() types.Rule { return &unusedConstRule{} },
+	})
+}
+
+func TestLintPackageRules(t *testing.T) {
+	var dir string
+	dir = t.TempDir()
+	var sources map[string]string
+	sources = map[string]string{
+		"a.go":      "package p\n\nconst A string = \"a\"\nconst B string = \"b\"\nconst C string = \"c\" //nolint:test-unused-const\n",
+		"b.go":      "package p\n\nvar used string = A\n",
+		"p_test.go": "package p_test\n\nconst D string = \"d\"\n",
+	}
+	var files []string
+	var name string
+	for _, name = range []string{"a.go", "b.go", "p_test.go"} {
+		var filename string
+		filename = filepath.Join(dir, name)
+		var err error
+		err = os.WriteFile(filename, []byte(sources[name]), 0o644)
+		if err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		files = append(files, filename)
+	}
+
+	var linter *Linter
+	var err error
+	linter, err = NewWithOptions(Options{Rules: []string{"test-unused-const"}})
+	if err != nil {
+		t.Fatalf("Failed to create linter: %v", err)
+	}
+
+	var issues []types.Issue
+	issues = linter.Lint(files)
+	var messages []string
+	var issue types.Issue
+	for _, issue = range issues {
+		messages = append(messages, filepath.Base(issue.File)+": "+issue.Message)
+	}
+	var got string
+	got = strings.Join(messages, "\n")
+	var expected string
+	expected = "a.go: Unused constant B\np_test.go: Unused constant D"
+	if got != expected {
+		t.Errorf("Expected issues:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+// TestLintParallel runs every registered rule on several packages with
+// one worker and with many: the issues must be the same, in the same
+// order. Run with -race, it checks the rules are safe for concurrent use.
+func TestLintParallel(t *testing.T) {
+	var root string
+	root = t.TempDir()
+	var files []string
+	var d int
+	for d = 0; d < 4; d++ {
+		var dir string
+		dir = filepath.Join(root, fmt.Sprintf("p%d", d))
+		var err error
+		err = os.Mkdir(dir, 0o755)
+		if err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		var f int
+		for f = 0; f < 10; f++ {
+			var filename string
+			filename = filepath.Join(dir, fmt.Sprintf("f%d.go", f))
+			err = os.WriteFile(filename, []byte(fmt.Sprintf(`package p
+
+const c%d = %d
+
+var v%d = c%d
+
+func f%d(x interface{}) (n int, err error) {
+	switch v := x.(type) {
+	case int:
+		n = v
+	}
+	if y := n; y > 0 {
+		goto end
+	}
+end:
+	return
+}
+`, f, f, f, f, f)), 0o644)
+			if err != nil {
+				t.Fatalf("Failed to write file: %v", err)
+			}
+			files = append(files, filename)
+		}
+	}
+
+	var names []string
+	var info rules.Info
+	for _, info = range rules.All() {
+		names = append(names, info.Name)
+	}
+
+	var sequential []types.Issue
+	var i int
+	var workers int
+	for i, workers = range []int{1, 8} {
+		var linter *Linter
+		var err error
+		linter, err = NewWithOptions(Options{Rules: names})
+		if err != nil {
+			t.Fatalf("Failed to create linter: %v", err)
+		}
+		linter.SetWorkers(workers)
+
+		var issues []types.Issue
+		issues = linter.Lint(files)
+		if i == 0 {
+			sequential = issues
+			if len(issues) == 0 {
+				t.Fatalf("Expected issues")
+			}
+			continue
+		}
+		if !reflect.DeepEqual(issues, sequential) {
+			t.Errorf("Expected the same issues with %d workers as with 1", workers)
+		}
+	}
+}
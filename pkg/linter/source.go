@@ -0,0 +1,135 @@
+package linter
+
+import (
+	"context"
+	"go/build"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/thierry-f-78/go-syntax/pkg/loader"
+	"github.com/thierry-f-78/go-syntax/pkg/types"
+)
+
+// SetOverlay makes the linter read the files of overlay, by path, instead
+// of those on disk, for the files linted as for the other files of their
+// packages the typed and package rules look at. The files of overlay need
+// not exist on disk. nil reads everything from disk, the default.
+func (l *Linter) SetOverlay(overlay map[string][]byte) {
+	l.typedMu.Lock()
+	defer l.typedMu.Unlock()
+	l.overlay = absOverlay(overlay)
+	l.resetImporters()
+}
+
+// LintSource lints the file filename whose content is src rather than
+// the one on disk, if any, such as the unsaved buffer of an editor. The
+// issues are reported for filename, whose directory gives the other files
+// of its package and its configuration. The packages it imports are read
+// from disk and the overlay set by SetOverlay. The suggested fixes are
+// edits of src, applied with fix.Apply.
+func (l *Linter) LintSource(filename string, src []byte) []types.Issue {
+	var overlay map[string][]byte
+	overlay = make(map[string][]byte, len(l.overlay)+1)
+	var name string
+	var content []byte
+	for name, content = range l.overlay {
+		overlay[name] = content
+	}
+	overlay[absPath(filename)] = src
+
+	var issues []types.Issue
+	issues, _ = l.lint(context.Background(), []string{filename}, overlay)
+	return issues
+}
+
+// LintReader is LintSource with the content of filename read from r.
+func (l *Linter) LintReader(filename string, r io.Reader) ([]types.Issue, error) {
+	var src []byte
+	var err error
+	src, err = io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return l.LintSource(filename, src), nil
+}
+
+// ReadFile returns the content of the file name as the linter reads it:
+// from the overlay set by SetOverlay if there, from disk otherwise.
+func (l *Linter) ReadFile(name string) ([]byte, error) {
+	return (&lintRun{overlay: l.overlay}).readFile(name)
+}
+
+// buildContext returns the build context of the linter reading overlay.
+func (l *Linter) buildContext(overlay map[string][]byte) *build.Context {
+	var ctxt *build.Context = l.ctxt
+	if ctxt == nil {
+		ctxt = &build.Default
+	}
+	if len(overlay) == 0 {
+		return ctxt
+	}
+	return loader.Overlay(ctxt, overlay)
+}
+
+// lintRun is the state of a run of Lint. The goroutines given up on after a
+// timeout keep using theirs while the next runs go on.
+type lintRun struct {
+	// overlay is the content of the files read instead of the disk, by
+	// absolute path.
+	overlay map[string][]byte
+	// typed are the type-checking of the packages for the typed rules, by
+	// directory. The typedMu of the linter guards the map.
+	typed map[string]*typedCheck
+}
+
+// readFile returns the content of the file name, from the overlay if
+// there.
+func (r *lintRun) readFile(name string) ([]byte, error) {
+	var src []byte
+	var ok bool
+	src, ok = r.overlay[absPath(name)]
+	if ok {
+		return src, nil
+	}
+	return os.ReadFile(name)
+}
+
+// overlayFiles returns the names of the Go files of the overlay in dir.
+func (r *lintRun) overlayFiles(dir string) []string {
+	var names []string
+	var name string
+	for name = range r.overlay {
+		if filepath.Dir(name) == absPath(dir) && filepath.Ext(name) == ".go" {
+			names = append(names, filepath.Base(name))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// absOverlay returns overlay keyed by absolute path.
+func absOverlay(overlay map[string][]byte) map[string][]byte {
+	if overlay == nil {
+		return nil
+	}
+	var abs map[string][]byte
+	abs = make(map[string][]byte, len(overlay))
+	var name string
+	var src []byte
+	for name, src = range overlay {
+		abs[absPath(name)] = src
+	}
+	return abs
+}
+
+func absPath(name string) string {
+	var abs string
+	var err error
+	abs, err = filepath.Abs(name)
+	if err != nil {
+		return filepath.Clean(name)
+	}
+	return abs
+}
@@ -0,0 +1,77 @@
+package linter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/thierry-f-78/go-syntax/pkg/types"
+)
+
+func TestLintSource(t *testing.T) {
+	var dir string
+	dir = t.TempDir()
+	var err error
+	err = os.WriteFile(filepath.Join(dir, "a.go"), []byte("package p\n\ntype y int\n"), 0o644)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, "b.go"), []byte("package p\n\nvar x int = 1\n"), 0o644)
+	}
+	if err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	var linter *Linter
+	linter, err = NewWithOptions(Options{Rules: []string{"var-no-type"}})
+	if err != nil {
+		t.Fatalf("Failed to create linter: %v", err)
+	}
+
+	// The source replaces b.go.
+	var issues []types.Issue
+	issues = linter.LintSource(filepath.Join(dir, "b.go"), []byte("package p\n\nvar x int = 1\nvar z = 2\n"))
+	if len(issues) != 1 || issues[0].Line != 4 || issues[0].File != filepath.Join(dir, "b.go") {
+		t.Errorf("Expected an issue at b.go:4, got %v", issues)
+	}
+
+	// The source of a file not on disk.
+	issues = linter.LintSource(filepath.Join(dir, "c.go"), []byte("package p\n\nvar z = 2\n"))
+	if len(issues) != 1 || issues[0].File != filepath.Join(dir, "c.go") {
+		t.Errorf("Expected an issue in c.go, got %v", issues)
+	}
+
+	// The overlay makes y a variable, reported by the typed check.
+	linter.SetOverlay(map[string][]byte{
+		filepath.Join(dir, "a.go"): []byte("package p\n\nvar y int = 1\n"),
+	})
+	issues = linter.LintSource(filepath.Join(dir, "b.go"), []byte("package p\n\nvar x = y\n"))
+	if len(issues) != 1 || issues[0].Line != 3 {
+		t.Errorf("Expected an issue at b.go:3, got %v", issues)
+	}
+
+	// The disk is back once the overlay is removed.
+	linter.SetOverlay(nil)
+	issues = linter.Lint([]string{filepath.Join(dir, "b.go")})
+	if len(issues) != 0 {
+		t.Errorf("Expected no issues, got %v", issues)
+	}
+}
+
+func TestApplyFixesOverlay(t *testing.T) {
+	var filename string
+	filename = writeTestFile(t, "package p\n\n// On disk, the file has more lines.\n\nvar a int = 1\n")
+
+	var linter *Linter
+	linter = New()
+	linter.SetOverlay(map[string][]byte{filename: []byte("package p\n\nvar x = f()\n\nfunc f() int { return 1 }\n")})
+	linter.SetFixes(true)
+
+	var results []FixResult
+	var err error
+	results, err = linter.ApplyFixes(linter.Lint([]string{filename}))
+	if err != nil {
+		t.Fatalf("Failed to apply fixes: %v", err)
+	}
+	if len(results) != 1 || string(results[0].After) != "package p\n\nvar x int = f()\n\nfunc f() int { return 1 }\n" {
+		t.Errorf("Expected the overlay to be fixed, got %v", results)
+	}
+}
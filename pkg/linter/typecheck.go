@@ -0,0 +1,189 @@
+package linter
+
+import (
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	gotypes "go/types"
+	"path/filepath"
+	"sync"
+
+	"github.com/thierry-f-78/go-syntax/pkg/loader"
+	"github.com/thierry-f-78/go-syntax/pkg/types"
+)
+
+// typedPackage is a package type-checked for the typed rules, its files
+// parsed with the names the linter was given.
+type typedPackage struct {
+	fset  *token.FileSet
+	files map[string]*ast.File
+	pkg   *gotypes.Package
+	info  *gotypes.Info
+}
+
+// hasTypedRule tells whether one of fileRules is a typed rule.
+func hasTypedRule(fileRules []types.Rule) bool {
+	var rule types.Rule
+	for _, rule = range fileRules {
+		var ok bool
+		_, ok = rule.(types.TypedRule)
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// typeCheck returns the type-checked package of filename, or nil when it
+// does not type-check: its typed rules then fall back to their syntactic
+// check. The packages are checked once, from source.
+func (l *Linter) typeCheck(r *lintRun, filename string) *typedPackage {
+	var ctxt *build.Context
+	ctxt = l.buildContext(r.overlay)
+
+	var dir string
+	var err error
+	dir, err = filepath.Abs(filepath.Dir(filename))
+	if err != nil {
+		return nil
+	}
+	var bp *build.Package
+	bp, err = ctxt.ImportDir(dir, 0)
+	if err != nil {
+		return nil
+	}
+
+	// The external tests are a package of their own.
+	var names []string
+	var path string
+	var key string
+	var base string
+	base = filepath.Base(filename)
+	switch {
+	case contains(bp.GoFiles, base) || contains(bp.CgoFiles, base) || contains(bp.TestGoFiles, base):
+		names = append(append(append(names, bp.GoFiles...), bp.CgoFiles...), bp.TestGoFiles...)
+		path = loader.ImportPath(dir)
+		key = dir
+	case contains(bp.XTestGoFiles, base):
+		names = bp.XTestGoFiles
+		path = loader.ImportPath(dir) + "_test"
+		key = dir + "_test"
+	default:
+		return nil
+	}
+
+	l.typedMu.Lock()
+	if r.typed == nil {
+		r.typed = make(map[string]*typedCheck)
+	}
+	var check *typedCheck
+	check = r.typed[key]
+	if check == nil {
+		check = new(typedCheck)
+		r.typed[key] = check
+	}
+	l.typedMu.Unlock()
+
+	check.once.Do(func() {
+		check.typed = l.checkPackage(r, filepath.Dir(filename), names, path)
+	})
+	return check.typed
+}
+
+// checkPackage type-checks the files names of dir as the package path, or
+// returns nil when they do not type-check.
+func (l *Linter) checkPackage(r *lintRun, dir string, names []string, path string) *typedPackage {
+	var typed *typedPackage
+	typed = &typedPackage{
+		fset:  token.NewFileSet(),
+		files: make(map[string]*ast.File),
+		info: &gotypes.Info{
+			Types:      make(map[ast.Expr]gotypes.TypeAndValue),
+			Defs:       make(map[*ast.Ident]gotypes.Object),
+			Uses:       make(map[*ast.Ident]gotypes.Object),
+			Implicits:  make(map[ast.Node]gotypes.Object),
+			Selections: make(map[*ast.SelectorExpr]*gotypes.Selection),
+			Scopes:     make(map[ast.Node]*gotypes.Scope),
+		},
+	}
+	var files []*ast.File
+	var name string
+	for _, name = range names {
+		name = filepath.Join(dir, name)
+		var src []byte
+		var err error
+		src, err = r.readFile(name)
+		if err != nil {
+			return nil
+		}
+		var file *ast.File
+		file, err = parser.ParseFile(typed.fset, name, src, parser.ParseComments)
+		if err != nil {
+			return nil
+		}
+		typed.files[filepath.Clean(name)] = file
+		files = append(files, file)
+	}
+
+	var imp *loader.Importer
+	var generation int
+	imp, generation = l.takeImporter()
+	defer l.releaseImporter(imp, generation)
+
+	var conf gotypes.Config
+	conf = gotypes.Config{
+		Importer:    imp,
+		FakeImportC: true,
+	}
+	var err error
+	typed.pkg, err = conf.Check(path, typed.fset, files, typed.info)
+	if err != nil {
+		return nil
+	}
+	return typed
+}
+
+// typedCheck is the type-checking of a package for the typed rules,
+// shared by its files: the first one checks the package while the others
+// wait for it.
+type typedCheck struct {
+	once  sync.Once
+	typed *typedPackage
+}
+
+// takeImporter returns an importer reading the overlay set by SetOverlay,
+// for the caller alone, and the generation of the build context it was
+// made for. An importer is not safe for concurrent use, so that each
+// package checked at a time has its own.
+func (l *Linter) takeImporter() (*loader.Importer, int) {
+	l.typedMu.Lock()
+	defer l.typedMu.Unlock()
+	var imp *loader.Importer
+	var last int
+	last = len(l.importers) - 1
+	if last >= 0 {
+		imp = l.importers[last]
+		l.importers = l.importers[:last]
+		return imp, l.generation
+	}
+	return loader.NewImporter(l.buildContext(l.overlay)), l.generation
+}
+
+// releaseImporter gives back an importer returned by takeImporter, for
+// other packages to reuse the packages it imported, unless the build
+// context changed since.
+func (l *Linter) releaseImporter(imp *loader.Importer, generation int) {
+	l.typedMu.Lock()
+	defer l.typedMu.Unlock()
+	if generation == l.generation {
+		l.importers = append(l.importers, imp)
+	}
+}
+
+// resetImporters drops the importers when the build context changes. The
+// caller holds typedMu.
+func (l *Linter) resetImporters() {
+	l.importers = nil
+	l.generation++
+}
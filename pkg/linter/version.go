@@ -0,0 +1,38 @@
+package linter
+
+import (
+	"runtime/debug"
+)
+
+// modulePath is the path of the module of go-syntax.
+const modulePath = "github.com/thierry-f-78/go-syntax"
+
+// Version returns the version of the go-syntax module built into the
+// program, or "devel" when it is not known, as in a build from a
+// checkout.
+func Version() string {
+	var info *debug.BuildInfo
+	var ok bool
+	info, ok = debug.ReadBuildInfo()
+	if !ok {
+		return "devel"
+	}
+
+	var version string
+	if info.Main.Path == modulePath {
+		version = info.Main.Version
+	}
+	var dep *debug.Module
+	for _, dep = range info.Deps {
+		if dep.Path == modulePath {
+			version = dep.Version
+			if dep.Replace != nil {
+				version = dep.Replace.Version
+			}
+		}
+	}
+	if version == "" || version == "(devel)" {
+		return "devel"
+	}
+	return version
+}
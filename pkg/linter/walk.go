@@ -0,0 +1,73 @@
+package linter
+
+import (
+	"go/ast"
+	"go/token"
+	"reflect"
+
+	"github.com/thierry-f-78/go-syntax/pkg/types"
+)
+
+// Walk checks pass.File with nodeRules, walking the file once: each node
+// goes to the rules visiting its type, along with its ancestors. The
+// issues are returned by rule, in the order of nodeRules. A rule that
+// panics gets an internal-error issue instead of its issues.
+func Walk(pass *types.Pass, nodeRules []types.NodeRule) [][]types.Issue {
+	var byType map[reflect.Type][]int
+	byType = make(map[reflect.Type][]int)
+	var i int
+	var rule types.NodeRule
+	for i, rule = range nodeRules {
+		var node ast.Node
+		for _, node = range rule.NodeTypes() {
+			byType[reflect.TypeOf(node)] = append(byType[reflect.TypeOf(node)], i)
+		}
+	}
+
+	var issues [][]types.Issue
+	issues = make([][]types.Issue, len(nodeRules))
+	var failed []bool
+	failed = make([]bool, len(nodeRules))
+	var stack []ast.Node
+	ast.Inspect(pass.File, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		var i int
+		for _, i = range byType[reflect.TypeOf(n)] {
+			if failed[i] {
+				continue
+			}
+			var found []types.Issue
+			var recovered interface{}
+			found, recovered = visit(pass, nodeRules[i], n, stack)
+			if recovered != nil {
+				// The issues of the rule are replaced by the report of
+				// its failure, and it visits no more nodes.
+				var pos token.Position
+				pos = pass.Fset.Position(n.Pos())
+				failed[i] = true
+				issues[i] = []types.Issue{internalError(pos.Filename, pos.Line, nodeRules[i].Name(), recovered)}
+				continue
+			}
+			issues[i] = append(issues[i], found...)
+		}
+		stack = append(stack, n)
+		return true
+	})
+	return issues
+}
+
+// visit calls rule.Visit, and returns the value it panicked with, if any.
+func visit(pass *types.Pass, rule types.NodeRule, n ast.Node, stack []ast.Node) ([]types.Issue, interface{}) {
+	var issues []types.Issue
+	var recovered interface{}
+	func() {
+		defer func() {
+			recovered = recover()
+		}()
+		issues = rule.Visit(pass, n, stack)
+	}()
+	return issues, recovered
+}
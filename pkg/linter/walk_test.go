@@ -0,0 +1,115 @@
+package linter
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/thierry-f-78/go-syntax/pkg/types"
+)
+
+// generatedSource returns a file of about size bytes, made of functions
+// with issues for each rule, like large generated files.
+func generatedSource(size int) string {
+	var b strings.Builder
+	b.WriteString("package generated\n")
+	var i int
+	for i = 0; b.Len() < size; i++ {
+		fmt.Fprintf(&b, `
+const c%d = %d
+
+var v%d = c%d + 1
+
+func f%d(x interface{}) (n int, err error) {
+	switch v := x.(type) {
+	case int:
+		n = v
+	}
+	y := n
+	if z := y; z > 0 {
+		return
+	}
+	for i := range []int{1, 2} {
+		n += i
+	}
+	return
+}
+`, i, i, i, i, i)
+	}
+	return b.String()
+}
+
+// nodeRules returns the node rules enabled by default.
+func nodeRules() []types.NodeRule {
+	var nodeRules []types.NodeRule
+	var rule types.Rule
+	for _, rule = range defaultRules() {
+		var nodeRule types.NodeRule
+		var ok bool
+		nodeRule, ok = rule.(types.NodeRule)
+		if ok {
+			nodeRules = append(nodeRules, nodeRule)
+		}
+	}
+	return nodeRules
+}
+
+func TestWalkMatchesCheck(t *testing.T) {
+	var fset *token.FileSet
+	fset = token.NewFileSet()
+	var file *ast.File
+	var err error
+	file, err = parser.ParseFile(fset, "generated.go", generatedSource(4096), parser.ParseComments)
+	if err != nil {
+		t.Fatalf("Failed to parse code: %v", err)
+	}
+
+	var rules []types.NodeRule
+	rules = nodeRules()
+	var visited [][]types.Issue
+	visited = Walk(&types.Pass{Fset: fset, File: file}, rules)
+	var i int
+	var rule types.NodeRule
+	for i, rule = range rules {
+		var checked []types.Issue
+		checked = rule.Check(fset, file)
+		if len(checked) == 0 {
+			t.Errorf("Expected issues for %s", rule.Name())
+		}
+		if !reflect.DeepEqual(visited[i], checked) {
+			t.Errorf("Expected the issues of %s to match Check: got %d, want %d", rule.Name(), len(visited[i]), len(checked))
+		}
+	}
+}
+
+// BenchmarkWalk walks files of growing sizes: the time per KB stays the
+// same as the analysis is linear.
+func BenchmarkWalk(b *testing.B) {
+	var size int
+	for _, size = range []int{1 << 20, 2 << 20, 4 << 20} {
+		var src string
+		src = generatedSource(size)
+		var fset *token.FileSet
+		fset = token.NewFileSet()
+		var file *ast.File
+		var err error
+		file, err = parser.ParseFile(fset, "generated.go", src, parser.ParseComments)
+		if err != nil {
+			b.Fatalf("Failed to parse code: %v", err)
+		}
+
+		var rules []types.NodeRule
+		rules = nodeRules()
+		b.Run(fmt.Sprintf("%dMB", size>>20), func(b *testing.B) {
+			var i int
+			for i = 0; i < b.N; i++ {
+				Walk(&types.Pass{Fset: fset, File: file}, rules)
+			}
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N)/float64(len(src)>>10), "ns/KB")
+		})
+	}
+}
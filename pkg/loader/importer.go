@@ -0,0 +1,372 @@
+package loader
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+// Importer is a go/types importer type-checking the imported packages
+// from source. It finds them in GOROOT, in the main modules, and for
+// their dependencies in the vendor directory, the local replacements or
+// the module cache: it never downloads anything. The bodies of the
+// functions of the imported packages are not checked.
+type Importer struct {
+	ctxt *build.Context
+	fset *token.FileSet
+
+	// pkgs are the imported packages by directory, nil while loading.
+	pkgs    map[string]*types.Package
+	errs    map[string]error
+	modules map[string]*moduleInfo
+}
+
+// moduleInfo is a module along with its requirements.
+type moduleInfo struct {
+	Module
+	// workspace lists the modules of go.work, if any.
+	workspace []*Module
+	requires  map[string]string
+	replaces  map[string]module.Version
+}
+
+// NewImporter returns an importer selecting the files of the packages
+// with ctxt. Cgo is disabled: the packages are checked in their pure Go
+// version.
+func NewImporter(ctxt *build.Context) *Importer {
+	var copied build.Context = *ctxt
+	copied.CgoEnabled = false
+	return &Importer{
+		ctxt:    &copied,
+		fset:    token.NewFileSet(),
+		pkgs:    make(map[string]*types.Package),
+		errs:    make(map[string]error),
+		modules: make(map[string]*moduleInfo),
+	}
+}
+
+// Import imports path from the current directory.
+func (imp *Importer) Import(path string) (*types.Package, error) {
+	return imp.ImportFrom(path, ".", 0)
+}
+
+// ImportFrom imports path for the package in the directory dir. The
+// dependencies are those required by the module of dir, for it and for
+// the packages it imports.
+func (imp *Importer) ImportFrom(path string, dir string, mode types.ImportMode) (*types.Package, error) {
+	var err error
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	var main *moduleInfo
+	main, err = imp.moduleInfo(dir)
+	if err != nil {
+		return nil, err
+	}
+	return imp.importFrom(path, dir, main)
+}
+
+// Dir returns the directory of the package path imported by the package
+// in the directory dir, for the main module of the directory main, without
+// importing it.
+func (imp *Importer) Dir(path string, dir string, main string) (string, error) {
+	var err error
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	main, err = filepath.Abs(main)
+	if err != nil {
+		return "", err
+	}
+	var info *moduleInfo
+	info, err = imp.moduleInfo(main)
+	if err != nil {
+		return "", err
+	}
+	return imp.find(path, dir, info)
+}
+
+// Immutable tells whether the package directory dir, as returned by Dir,
+// cannot change: it is in GOROOT or in the module cache.
+func (imp *Importer) Immutable(dir string) bool {
+	var root string
+	for _, root = range []string{filepath.Join(imp.ctxt.GOROOT, "src"), modCache(imp.ctxt)} {
+		if strings.HasPrefix(dir, root+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// importFrom imports path for the package in the directory dir, in the
+// main module main.
+func (imp *Importer) importFrom(path string, dir string, main *moduleInfo) (*types.Package, error) {
+	if path == "unsafe" {
+		return types.Unsafe, nil
+	}
+
+	var pkgDir string
+	var err error
+	pkgDir, err = imp.find(path, dir, main)
+	if err != nil {
+		return nil, err
+	}
+
+	var pkg *types.Package
+	var loaded bool
+	pkg, loaded = imp.pkgs[pkgDir]
+	err = imp.errs[pkgDir]
+	if !loaded {
+		// Mark the package as loading to catch import cycles.
+		imp.pkgs[pkgDir] = nil
+	}
+
+	if loaded {
+		if pkg == nil && err == nil {
+			return nil, fmt.Errorf("import cycle through %q", path)
+		}
+		return pkg, err
+	}
+
+	pkg, err = imp.check(path, pkgDir, main)
+
+	imp.pkgs[pkgDir] = pkg
+	imp.errs[pkgDir] = err
+	return pkg, err
+}
+
+// mainImporter imports the packages for the main module main.
+type mainImporter struct {
+	imp  *Importer
+	main *moduleInfo
+}
+
+func (m *mainImporter) Import(path string) (*types.Package, error) {
+	return m.imp.importFrom(path, "", m.main)
+}
+
+func (m *mainImporter) ImportFrom(path string, dir string, mode types.ImportMode) (*types.Package, error) {
+	return m.imp.importFrom(path, dir, m.main)
+}
+
+// check type-checks the package path in dir, ignoring its errors as long
+// as it has files.
+func (imp *Importer) check(path string, dir string, main *moduleInfo) (*types.Package, error) {
+	var bp *build.Package
+	var err error
+	bp, err = imp.ctxt.ImportDir(dir, 0)
+	if err != nil {
+		return nil, fmt.Errorf("importing %q: %w", path, err)
+	}
+
+	var files []*ast.File
+	var name string
+	for _, name = range bp.GoFiles {
+		var src []byte
+		src, err = ReadFile(imp.ctxt, filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("importing %q: %w", path, err)
+		}
+		var file *ast.File
+		file, err = parser.ParseFile(imp.fset, filepath.Join(dir, name), src, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, fmt.Errorf("importing %q: %w", path, err)
+		}
+		files = append(files, file)
+	}
+
+	var conf types.Config
+	conf = types.Config{
+		Importer:         &mainImporter{imp: imp, main: main},
+		IgnoreFuncBodies: true,
+		FakeImportC:      true,
+		Error:            func(error) {},
+	}
+	var pkg *types.Package
+	pkg, _ = conf.Check(path, imp.fset, files, nil)
+	return pkg, nil
+}
+
+// find returns the directory of the package path imported from the
+// directory dir, in the main module info.
+func (imp *Importer) find(path string, dir string, info *moduleInfo) (string, error) {
+	var goroot string
+	goroot = filepath.Join(imp.ctxt.GOROOT, "src")
+
+	// The standard library, and its vendored packages.
+	if !strings.Contains(strings.Split(path, "/")[0], ".") {
+		return existingDir(path, filepath.Join(goroot, filepath.FromSlash(path)))
+	}
+	if dir == goroot || strings.HasPrefix(dir, goroot+string(filepath.Separator)) {
+		return existingDir(path, filepath.Join(goroot, "vendor", filepath.FromSlash(path)))
+	}
+
+	if info == nil {
+		return "", fmt.Errorf("cannot find package %q: %s is not in a module", path, dir)
+	}
+
+	var main *Module
+	for _, main = range append([]*Module{&info.Module}, info.workspace...) {
+		if path == main.Path || strings.HasPrefix(path, main.Path+"/") {
+			return existingDir(path, filepath.Join(main.Dir, filepath.FromSlash(strings.TrimPrefix(path, main.Path))))
+		}
+	}
+
+	var vendored string
+	vendored = filepath.Join(info.Dir, "vendor", filepath.FromSlash(path))
+	if dirExists(vendored) {
+		return vendored, nil
+	}
+
+	// The dependency providing path, the one with the longest path.
+	var modulePath string
+	var candidate string
+	for candidate = range info.requires {
+		if (path == candidate || strings.HasPrefix(path, candidate+"/")) && len(candidate) > len(modulePath) {
+			modulePath = candidate
+		}
+	}
+	if modulePath == "" {
+		return "", fmt.Errorf("cannot find package %q: no module of %s provides it", path, info.Dir)
+	}
+
+	var root string
+	var err error
+	root, err = imp.moduleDir(info, modulePath)
+	if err != nil {
+		return "", fmt.Errorf("cannot find package %q: %w", path, err)
+	}
+	return existingDir(path, filepath.Join(root, filepath.FromSlash(strings.TrimPrefix(path, modulePath))))
+}
+
+// moduleDir returns the directory of the dependency modulePath of info:
+// its local replacement, or its directory in the module cache.
+func (imp *Importer) moduleDir(info *moduleInfo, modulePath string) (string, error) {
+	var version module.Version
+	version = module.Version{Path: modulePath, Version: info.requires[modulePath]}
+	var replacement module.Version
+	var ok bool
+	replacement, ok = info.replaces[modulePath]
+	if ok {
+		if replacement.Version == "" {
+			if filepath.IsAbs(replacement.Path) {
+				return replacement.Path, nil
+			}
+			return filepath.Join(info.Dir, filepath.FromSlash(replacement.Path)), nil
+		}
+		version = replacement
+	}
+
+	var escapedPath string
+	var escapedVersion string
+	var err error
+	escapedPath, err = module.EscapePath(version.Path)
+	if err != nil {
+		return "", err
+	}
+	escapedVersion, err = module.EscapeVersion(version.Version)
+	if err != nil {
+		return "", err
+	}
+
+	var dir string
+	dir = filepath.Join(modCache(imp.ctxt), escapedPath+"@"+escapedVersion)
+	if !dirExists(dir) {
+		return "", fmt.Errorf("module %s@%s is not in the module cache", version.Path, version.Version)
+	}
+	return dir, nil
+}
+
+// moduleInfo returns the module containing the directory dir, or nil.
+func (imp *Importer) moduleInfo(dir string) (*moduleInfo, error) {
+	var found *Module
+	var err error
+	found, err = findModule(dir)
+	if err != nil || found == nil {
+		return nil, err
+	}
+
+	var info *moduleInfo
+	info = imp.modules[found.Dir]
+	if info != nil {
+		return info, nil
+	}
+
+	info = &moduleInfo{
+		Module:   *found,
+		requires: make(map[string]string),
+		replaces: make(map[string]module.Version),
+	}
+	var gomod string
+	gomod = filepath.Join(found.Dir, "go.mod")
+	var data []byte
+	data, err = os.ReadFile(gomod)
+	if err != nil {
+		return nil, err
+	}
+	var file *modfile.File
+	file, err = modfile.Parse(gomod, data, nil)
+	if err != nil {
+		return nil, err
+	}
+	var require *modfile.Require
+	for _, require = range file.Require {
+		info.requires[require.Mod.Path] = require.Mod.Version
+	}
+	var replace *modfile.Replace
+	for _, replace = range file.Replace {
+		info.replaces[replace.Old.Path] = replace.New
+	}
+
+	var work string
+	work, err = findWork(found.Dir)
+	if err == nil && work != "" {
+		info.workspace, err = workModules(work)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	imp.modules[found.Dir] = info
+	return info, nil
+}
+
+// modCache returns the module cache directory, like the go command.
+func modCache(ctxt *build.Context) string {
+	var dir string
+	dir = os.Getenv("GOMODCACHE")
+	if dir != "" {
+		return dir
+	}
+	return filepath.Join(filepath.SplitList(ctxt.GOPATH)[0], "pkg", "mod")
+}
+
+func existingDir(path string, dir string) (string, error) {
+	if !dirExists(dir) {
+		return "", fmt.Errorf("cannot find package %q in %s", path, dir)
+	}
+	return dir, nil
+}
+
+// ImportPath returns the import path of the package in the absolute
+// directory dir, or its directory when it is not in a module.
+func ImportPath(dir string) string {
+	var found *Module
+	var err error
+	found, err = findModule(dir)
+	if err != nil || found == nil {
+		return dir
+	}
+	return importPath(found, dir)
+}
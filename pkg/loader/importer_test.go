@@ -0,0 +1,52 @@
+package loader
+
+import (
+	"go/build"
+	"go/types"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImporter(t *testing.T) {
+	t.Setenv("GOWORK", "off")
+
+	var root string
+	root = writeTree(t, map[string]string{
+		"m/go.mod": "module example.com/m\n\ngo 1.22\n\n" +
+			"require (\n\texample.com/dep v1.0.0\n\texample.com/local v1.0.0\n\texample.com/missing v1.0.0\n)\n\n" +
+			"replace example.com/local => ../local\n",
+		"m/a/a.go": "package a\n\nimport \"example.com/m/b\"\n\nvar A = b.B\n",
+		"m/b/b.go": "package b\n\nimport (\n\t\"strings\"\n\n\t\"example.com/dep\"\n\t\"example.com/local\"\n)\n\n" +
+			"var B = strings.Repeat(dep.Dep, local.Local)\n",
+		"cache/example.com/dep@v1.0.0/dep.go": "package dep\n\nconst Dep = \"dep\"\n",
+		"local/local.go":                      "package local\n\nconst Local = 2\n",
+	})
+	t.Setenv("GOMODCACHE", filepath.Join(root, "cache"))
+
+	var imp *Importer
+	imp = NewImporter(&build.Default)
+
+	var dir string
+	dir = filepath.Join(root, "m")
+	var pkg *types.Package
+	var err error
+	pkg, err = imp.ImportFrom("example.com/m/a", dir, 0)
+	if err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+	var a types.Object
+	a = pkg.Scope().Lookup("A")
+	if a == nil || a.Type().String() != "string" {
+		t.Errorf("Expected A of type string, got %v", a)
+	}
+
+	_, err = imp.ImportFrom("example.com/missing", dir, 0)
+	if err == nil || !strings.Contains(err.Error(), "not in the module cache") {
+		t.Errorf("Expected a missing module error, got %v", err)
+	}
+	_, err = imp.ImportFrom("example.com/unknown", dir, 0)
+	if err == nil || !strings.Contains(err.Error(), "no module") {
+		t.Errorf("Expected an unknown module error, got %v", err)
+	}
+}
@@ -0,0 +1,596 @@
+// Package loader finds the Go packages matching command line patterns,
+// the way go list does, without running the go command:
+//
+//	.             the package in the current directory
+//	./pkg/...     the packages in pkg and below, in the same module
+//	example.com/m/sub, example.com/m/...
+//	              packages of the main modules, or of the standard library
+//	main.go       a file, taken as is
+//
+// The files are selected by their build constraints for the target
+// GOOS, GOARCH and build tags. A directory with its own go.mod is another
+// module, left out of the ./... patterns unless go.work uses it.
+package loader
+
+import (
+	"errors"
+	"fmt"
+	"go/build"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// Config drives the loading of packages.
+type Config struct {
+	// Dir is the directory the patterns are relative to. The empty string
+	// is the current directory.
+	Dir string
+	// Tags are the build tags satisfied, in addition to GOOS and GOARCH.
+	Tags []string
+	// GOOS and GOARCH are the target system. They default to the ones of
+	// the environment, like for the go command.
+	GOOS   string
+	GOARCH string
+}
+
+// Module is a module of the workspace.
+type Module struct {
+	Path string
+	Dir  string
+}
+
+// Package is a loaded package. Its files are paths joined to Dir, which
+// is relative to the Config directory when the pattern is.
+type Package struct {
+	// ImportPath is empty for files outside any module.
+	ImportPath string
+	Name       string
+	Dir        string
+	Module     *Module
+
+	// GoFiles are the files of the package, cgo and invalid files
+	// included, the latter left for the linter to report.
+	GoFiles []string
+	// TestGoFiles are the _test.go files of the package, XTestGoFiles the
+	// ones of the external test package.
+	TestGoFiles  []string
+	XTestGoFiles []string
+}
+
+// Files returns all the files of p, tests included.
+func (p *Package) Files() []string {
+	var files []string
+	files = append(files, p.GoFiles...)
+	files = append(files, p.TestGoFiles...)
+	files = append(files, p.XTestGoFiles...)
+	return files
+}
+
+// Context returns the build context selecting the files.
+func (c *Config) Context() *build.Context {
+	var ctxt build.Context = build.Default
+	if c.GOOS != "" {
+		ctxt.GOOS = c.GOOS
+	}
+	if c.GOARCH != "" {
+		ctxt.GOARCH = c.GOARCH
+	}
+	ctxt.BuildTags = append([]string(nil), c.Tags...)
+	return &ctxt
+}
+
+// Load returns the packages matching patterns, each one once, in the
+// order of the patterns then of the directories. The packages that could
+// be loaded are returned along with the errors of the others.
+func (c *Config) Load(patterns ...string) ([]*Package, error) {
+	var l *loader
+	var err error
+	l, err = c.newLoader()
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	var pattern string
+	for _, pattern = range patterns {
+		err = l.load(pattern)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return l.pkgs, errors.Join(errs...)
+}
+
+// loader holds the state of one Load.
+type loader struct {
+	config *Config
+	ctxt   *build.Context
+	// dir is the absolute Config directory.
+	dir string
+	// modules are the main modules: the modules of go.work, or the module
+	// of the Config directory.
+	modules []*Module
+
+	pkgs []*Package
+	seen map[string]*Package
+}
+
+func (c *Config) newLoader() (*loader, error) {
+	var l *loader
+	l = &loader{
+		config: c,
+		ctxt:   c.Context(),
+		seen:   make(map[string]*Package),
+	}
+
+	var err error
+	l.dir, err = filepath.Abs(c.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var work string
+	work, err = findWork(l.dir)
+	if err != nil {
+		return nil, err
+	}
+	if work != "" {
+		l.modules, err = workModules(work)
+		return l, err
+	}
+
+	var module *Module
+	module, err = findModule(l.dir)
+	if err != nil {
+		return nil, err
+	}
+	if module != nil {
+		l.modules = []*Module{module}
+	}
+	return l, nil
+}
+
+// load adds the packages matching pattern.
+func (l *loader) load(pattern string) error {
+	if strings.HasSuffix(pattern, ".go") {
+		return l.loadFile(pattern)
+	}
+	if l.isLocal(pattern) {
+		if strings.HasSuffix(pattern, "/...") || pattern == "..." {
+			return l.walk(strings.TrimSuffix(strings.TrimSuffix(pattern, "..."), "/"), nil)
+		}
+		return l.loadDir(pattern, true)
+	}
+	return l.loadImportPath(pattern)
+}
+
+// isLocal tells whether pattern names directories rather than import
+// paths. Relative paths not starting with a dot are directories when they
+// exist, as the previous versions of go-syntax took them.
+func (l *loader) isLocal(pattern string) bool {
+	if pattern == "." || pattern == ".." || pattern == "..." || filepath.IsAbs(pattern) ||
+		strings.HasPrefix(pattern, "./") || strings.HasPrefix(pattern, "../") {
+		return true
+	}
+
+	var dir string
+	dir = strings.TrimSuffix(strings.TrimSuffix(pattern, "..."), "/")
+	var info os.FileInfo
+	var err error
+	info, err = os.Stat(l.path(dir))
+	return err == nil && info.IsDir()
+}
+
+// path returns the path of name, relative to the Config directory.
+func (l *loader) path(name string) string {
+	if filepath.IsAbs(name) || l.config.Dir == "" {
+		return filepath.Clean(name)
+	}
+	return filepath.Join(l.config.Dir, name)
+}
+
+// abs returns the absolute path of name, relative to the Config
+// directory.
+func (l *loader) abs(name string) string {
+	if filepath.IsAbs(name) {
+		return filepath.Clean(name)
+	}
+	return filepath.Join(l.dir, name)
+}
+
+// relative returns the absolute directory dir relative to the Config
+// directory when it is below, absolute otherwise.
+func (l *loader) relative(dir string) string {
+	var rel string
+	var err error
+	rel, err = filepath.Rel(l.dir, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return dir
+	}
+	return rel
+}
+
+// loadFile adds the file name, whatever its build constraints, as a
+// package of its own directory.
+func (l *loader) loadFile(name string) error {
+	var info os.FileInfo
+	var err error
+	info, err = os.Stat(l.path(name))
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", name)
+	}
+
+	var abs string
+	abs = filepath.Dir(l.abs(name))
+	var key string
+	key = "file:" + abs
+	var pkg *Package
+	pkg = l.seen[key]
+	if pkg == nil {
+		pkg = &Package{Dir: filepath.Dir(l.path(name))}
+		pkg.Module, err = l.moduleOf(abs)
+		if err != nil {
+			return err
+		}
+		pkg.ImportPath = importPath(pkg.Module, abs)
+		l.seen[key] = pkg
+		l.pkgs = append(l.pkgs, pkg)
+	}
+
+	var file string = l.path(name)
+	var existing string
+	for _, existing = range pkg.Files() {
+		if existing == file {
+			return nil
+		}
+	}
+	if strings.HasSuffix(file, "_test.go") {
+		pkg.TestGoFiles = append(pkg.TestGoFiles, file)
+	} else {
+		pkg.GoFiles = append(pkg.GoFiles, file)
+	}
+	return nil
+}
+
+// loadDir adds the package in dir, spelled as the user did. Unless
+// explicit, a directory without Go files is silently skipped.
+func (l *loader) loadDir(dir string, explicit bool) error {
+	var abs string
+	abs = l.abs(dir)
+	if l.seen[abs] != nil {
+		return nil
+	}
+
+	var module *Module
+	var err error
+	module, err = l.moduleOf(abs)
+	if err != nil {
+		return err
+	}
+
+	var bp *build.Package
+	bp, err = l.ctxt.ImportDir(abs, 0)
+	var noGo *build.NoGoError
+	if errors.As(err, &noGo) {
+		if explicit {
+			return fmt.Errorf("no Go files in %s", l.path(dir))
+		}
+		return nil
+	}
+	var multiple *build.MultiplePackageError
+	if errors.As(err, &multiple) {
+		return err
+	}
+	if err != nil && len(bp.InvalidGoFiles) == 0 {
+		return fmt.Errorf("%s: %w", l.path(dir), err)
+	}
+
+	var pkg *Package
+	pkg = &Package{
+		ImportPath: importPath(module, abs),
+		Name:       bp.Name,
+		Dir:        l.path(dir),
+		Module:     module,
+	}
+	var names []string
+	names = append(append(append(names, bp.GoFiles...), bp.CgoFiles...), bp.InvalidGoFiles...)
+	sort.Strings(names)
+	pkg.GoFiles = join(pkg.Dir, names)
+	pkg.TestGoFiles = join(pkg.Dir, bp.TestGoFiles)
+	pkg.XTestGoFiles = join(pkg.Dir, bp.XTestGoFiles)
+
+	l.seen[abs] = pkg
+	l.pkgs = append(l.pkgs, pkg)
+	return nil
+}
+
+func join(dir string, names []string) []string {
+	var files []string
+	var name string
+	for _, name = range names {
+		files = append(files, filepath.Join(dir, name))
+	}
+	return files
+}
+
+// walk adds the packages in root and below, spelled relative to it, in
+// the modules of the walk: the module of root, and the workspace modules.
+// When match is set, only the import paths it matches are added.
+func (l *loader) walk(root string, match *regexp.Regexp) error {
+	if root == "" {
+		root = "."
+	}
+	var rootAbs string
+	rootAbs = l.abs(root)
+
+	var errs []error
+	var err error
+	err = filepath.WalkDir(rootAbs, func(current string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+
+		if current != rootAbs {
+			var name string = entry.Name()
+			if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata" || name == "vendor" {
+				return filepath.SkipDir
+			}
+			// Another module, unless the workspace uses it.
+			var module *Module
+			module = l.mainModule(current)
+			if fileExists(filepath.Join(current, "go.mod")) && (module == nil || module.Dir != current) {
+				return filepath.SkipDir
+			}
+		}
+
+		var rel string
+		rel, _ = filepath.Rel(rootAbs, current)
+		var dir string = filepath.Join(root, rel)
+		if filepath.IsAbs(root) {
+			dir = current
+		}
+		if match != nil {
+			var module *Module
+			module, err = l.moduleOf(current)
+			if err != nil {
+				return err
+			}
+			if !match.MatchString(importPath(module, current)) {
+				return nil
+			}
+			dir = l.relative(current)
+		}
+
+		err = l.loadDir(dir, false)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		return nil
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// loadImportPath adds the packages matching the import path pattern, in
+// the main modules or in the standard library.
+func (l *loader) loadImportPath(pattern string) error {
+	var prefix string = pattern
+	var match *regexp.Regexp
+	var i int
+	i = strings.Index(pattern, "...")
+	if i >= 0 {
+		prefix = strings.TrimSuffix(pattern[:i], "/")
+		match = matchPattern(pattern)
+	}
+
+	var module *Module
+	for _, module = range l.modules {
+		var dir string
+		switch {
+		case prefix == module.Path:
+			dir = module.Dir
+		case strings.HasPrefix(prefix, module.Path+"/"):
+			dir = filepath.Join(module.Dir, filepath.FromSlash(strings.TrimPrefix(prefix, module.Path+"/")))
+		case match != nil && strings.HasPrefix(module.Path, prefix):
+			dir = module.Dir
+		default:
+			continue
+		}
+
+		if match != nil {
+			return l.walk(dir, match)
+		}
+		return l.loadDir(l.relative(dir), true)
+	}
+
+	var std string
+	std = filepath.Join(l.ctxt.GOROOT, "src", filepath.FromSlash(prefix))
+	if prefix != "" && dirExists(std) {
+		if match != nil {
+			return l.walk(std, match)
+		}
+		return l.loadDir(std, true)
+	}
+	return fmt.Errorf("cannot find package %q in the main modules or the standard library", pattern)
+}
+
+// matchPattern returns the regular expression matching the import paths
+// of the pattern, where ... matches any string.
+func matchPattern(pattern string) *regexp.Regexp {
+	var re string
+	re = regexp.QuoteMeta(pattern)
+	re = strings.ReplaceAll(re, `\.\.\.`, `.*`)
+	// "a/..." also matches "a".
+	if strings.HasSuffix(re, `/.*`) {
+		re = strings.TrimSuffix(re, `/.*`) + `(/.*)?`
+	}
+	return regexp.MustCompile("^" + re + "$")
+}
+
+// moduleOf returns the module containing the absolute directory dir, or
+// nil.
+func (l *loader) moduleOf(dir string) (*Module, error) {
+	var module *Module
+	module = l.mainModule(dir)
+	if module != nil {
+		return module, nil
+	}
+	return findModule(dir)
+}
+
+// mainModule returns the main module rooted at dir or above, the
+// innermost one, or nil.
+func (l *loader) mainModule(dir string) *Module {
+	var found *Module
+	var module *Module
+	for _, module = range l.modules {
+		if (dir == module.Dir || strings.HasPrefix(dir, module.Dir+string(filepath.Separator))) &&
+			(found == nil || len(module.Dir) > len(found.Dir)) {
+			found = module
+		}
+	}
+	return found
+}
+
+// importPath returns the import path of the absolute directory dir of
+// module. The packages of the standard library module have no prefix.
+func importPath(module *Module, dir string) string {
+	if module == nil {
+		return ""
+	}
+	var rel string
+	rel, _ = filepath.Rel(module.Dir, dir)
+	if module.Path == "std" {
+		return filepath.ToSlash(rel)
+	}
+	if rel == "." {
+		return module.Path
+	}
+	return path.Join(module.Path, filepath.ToSlash(rel))
+}
+
+// findModule returns the module whose go.mod is in dir or above, or nil.
+func findModule(dir string) (*Module, error) {
+	for {
+		var gomod string
+		gomod = filepath.Join(dir, "go.mod")
+		var data []byte
+		var err error
+		data, err = os.ReadFile(gomod)
+		if err == nil {
+			var modulePath string
+			modulePath = modfile.ModulePath(data)
+			if modulePath == "" {
+				return nil, fmt.Errorf("%s: no module directive", gomod)
+			}
+			return &Module{Path: modulePath, Dir: dir}, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+
+		var parent string
+		parent = filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// findWork returns the go.work file of dir, following GOWORK like the go
+// command, or the empty string.
+func findWork(dir string) (string, error) {
+	var gowork string
+	gowork = os.Getenv("GOWORK")
+	switch gowork {
+	case "off":
+		return "", nil
+	case "":
+	default:
+		return gowork, nil
+	}
+
+	for {
+		var work string
+		work = filepath.Join(dir, "go.work")
+		if fileExists(work) {
+			return work, nil
+		}
+		var parent string
+		parent = filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// workModules returns the modules used by the go.work file work.
+func workModules(work string) ([]*Module, error) {
+	var data []byte
+	var err error
+	data, err = os.ReadFile(work)
+	if err != nil {
+		return nil, err
+	}
+
+	var file *modfile.WorkFile
+	file, err = modfile.ParseWork(work, data, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var modules []*Module
+	var use *modfile.Use
+	for _, use = range file.Use {
+		var dir string = filepath.FromSlash(use.Path)
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(filepath.Dir(work), dir)
+		}
+		dir, err = filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		var module *Module
+		module, err = findModule(dir)
+		if err != nil {
+			return nil, err
+		}
+		if module == nil || module.Dir != dir {
+			return nil, fmt.Errorf("%s: no go.mod in %s", work, use.Path)
+		}
+		modules = append(modules, module)
+	}
+	return modules, nil
+}
+
+func fileExists(name string) bool {
+	var info os.FileInfo
+	var err error
+	info, err = os.Stat(name)
+	return err == nil && !info.IsDir()
+}
+
+func dirExists(name string) bool {
+	var info os.FileInfo
+	var err error
+	info, err = os.Stat(name)
+	return err == nil && info.IsDir()
+}
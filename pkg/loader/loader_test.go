@@ -0,0 +1,224 @@
+package loader
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTree writes files, by slash-separated path, under a new temporary
+// directory and returns it.
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	var root string
+	root = t.TempDir()
+	var name string
+	var content string
+	for name, content = range files {
+		var path string
+		path = filepath.Join(root, filepath.FromSlash(name))
+		var err error
+		err = os.MkdirAll(filepath.Dir(path), 0o755)
+		if err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		err = os.WriteFile(path, []byte(content), 0o644)
+		if err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+	return root
+}
+
+// describe lists the packages as "import/path: file, file".
+func describe(pkgs []*Package) string {
+	var lines []string
+	var pkg *Package
+	for _, pkg = range pkgs {
+		var names []string
+		var file string
+		for _, file = range pkg.Files() {
+			names = append(names, filepath.ToSlash(file))
+		}
+		lines = append(lines, pkg.ImportPath+": "+strings.Join(names, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestLoad(t *testing.T) {
+	t.Setenv("GOWORK", "off")
+
+	var root string
+	root = writeTree(t, map[string]string{
+		"go.mod":                   "module example.com/m\n\ngo 1.22\n",
+		"main.go":                  "package main\n",
+		"main_test.go":             "package main\n",
+		"ext_test.go":              "package main_test\n",
+		"linux.go":                 "//go:build linux\n\npackage main\n",
+		"tagged.go":                "//go:build extra\n\npackage main\n",
+		"sub/sub.go":               "package sub\n",
+		"sub/testdata/data.go":     "package data\n",
+		"sub/vendor/v/v.go":        "package v\n",
+		"sub/.hidden/h.go":         "package h\n",
+		"empty/README":             "",
+		"nested/go.mod":            "module example.com/nested\n",
+		"nested/nested.go":         "package nested\n",
+		"broken/broken.go":         "package broken\nfunc {\n",
+		"other/ignored.go":         "//go:build ignore\n\npackage other\n",
+		"other/sub/deeper/deep.go": "package deeper\n",
+	})
+
+	var tests []struct {
+		name     string
+		config   Config
+		patterns []string
+		expected string
+		err      string
+	}
+	tests = []struct {
+		name     string
+		config   Config
+		patterns []string
+		expected string
+		err      string
+	}{
+		{
+			name:     "current directory",
+			config:   Config{Dir: root, GOOS: "linux"},
+			patterns: []string{"."},
+			expected: "example.com/m: linux.go, main.go, main_test.go, ext_test.go",
+		},
+		{
+			name:     "build tags and GOOS",
+			config:   Config{Dir: root, GOOS: "windows", Tags: []string{"extra"}},
+			patterns: []string{"."},
+			expected: "example.com/m: main.go, tagged.go, main_test.go, ext_test.go",
+		},
+		{
+			name:     "recursive pattern stops at nested modules",
+			config:   Config{Dir: root, GOOS: "windows"},
+			patterns: []string{"./..."},
+			expected: "example.com/m: main.go, main_test.go, ext_test.go\n" +
+				"example.com/m/broken: broken/broken.go\n" +
+				"example.com/m/other/sub/deeper: other/sub/deeper/deep.go\n" +
+				"example.com/m/sub: sub/sub.go",
+		},
+		{
+			name:     "import paths",
+			config:   Config{Dir: root},
+			patterns: []string{"example.com/m/sub", "example.com/m/other/..."},
+			expected: "example.com/m/sub: sub/sub.go\n" +
+				"example.com/m/other/sub/deeper: other/sub/deeper/deep.go",
+		},
+		{
+			name:     "directory without ./ and file",
+			config:   Config{Dir: root},
+			patterns: []string{"sub", "other/ignored.go"},
+			expected: "example.com/m/sub: sub/sub.go\n" +
+				"example.com/m/other: other/ignored.go",
+		},
+		{
+			name:     "packages are loaded once",
+			config:   Config{Dir: root},
+			patterns: []string{"./sub", "./sub/...", "example.com/m/sub"},
+			expected: "example.com/m/sub: sub/sub.go",
+		},
+		{
+			name:     "directory without Go files",
+			config:   Config{Dir: root},
+			patterns: []string{"./empty"},
+			err:      "no Go files in",
+		},
+		{
+			name:     "unknown import path",
+			config:   Config{Dir: root},
+			patterns: []string{"example.com/other"},
+			err:      `cannot find package "example.com/other"`,
+		},
+	}
+
+	var tt struct {
+		name     string
+		config   Config
+		patterns []string
+		expected string
+		err      string
+	}
+	for _, tt = range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pkgs []*Package
+			var err error
+			pkgs, err = tt.config.Load(tt.patterns...)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Expected error %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to load: %v", err)
+			}
+
+			var got string
+			got = strings.ReplaceAll(describe(pkgs), filepath.ToSlash(root)+"/", "")
+			if got != tt.expected {
+				t.Errorf("Expected:\n%s\nGot:\n%s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestLoadStandardLibrary(t *testing.T) {
+	var config Config
+	var pkgs []*Package
+	var err error
+	pkgs, err = config.Load("unicode/...")
+	if err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+
+	var paths []string
+	var pkg *Package
+	for _, pkg = range pkgs {
+		paths = append(paths, pkg.ImportPath)
+	}
+	var expected string = "unicode,unicode/utf16,unicode/utf8"
+	if strings.Join(paths, ",") != expected {
+		t.Errorf("Expected packages %s, got %s", expected, strings.Join(paths, ","))
+	}
+}
+
+func TestLoadWorkspace(t *testing.T) {
+	t.Setenv("GOWORK", "")
+
+	var root string
+	root = writeTree(t, map[string]string{
+		"go.work":          "go 1.22\n\nuse (\n\t./a\n\t./b\n)\n",
+		"a/go.mod":         "module example.com/a\n",
+		"a/a.go":           "package a\n",
+		"b/go.mod":         "module example.com/b\n",
+		"b/b.go":           "package b\n",
+		"b/c/go.mod":       "module example.com/c\n",
+		"b/c/c.go":         "package c\n",
+		"unused/go.mod":    "module example.com/unused\n",
+		"unused/unused.go": "package unused\n",
+	})
+
+	var config Config
+	config = Config{Dir: root}
+	var pkgs []*Package
+	var err error
+	pkgs, err = config.Load("./...", "example.com/b")
+	if err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+
+	var expected string = "example.com/a: a/a.go\nexample.com/b: b/b.go"
+	var got string
+	got = strings.ReplaceAll(describe(pkgs), filepath.ToSlash(root)+"/", "")
+	if got != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, got)
+	}
+}
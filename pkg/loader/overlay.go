@@ -0,0 +1,136 @@
+package loader
+
+import (
+	"bytes"
+	"go/build"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Overlay returns a copy of ctxt reading the files of overlay, by path,
+// instead of those on disk, such as the unsaved buffers of an editor.
+// The files of overlay need not exist on disk.
+func Overlay(ctxt *build.Context, overlay map[string][]byte) *build.Context {
+	var files map[string][]byte
+	files = make(map[string][]byte)
+	var name string
+	var content []byte
+	for name, content = range overlay {
+		files[absPath(name)] = content
+	}
+
+	var copied build.Context = *ctxt
+	copied.OpenFile = func(name string) (io.ReadCloser, error) {
+		var content []byte
+		var ok bool
+		content, ok = files[absPath(name)]
+		if ok {
+			return io.NopCloser(bytes.NewReader(content)), nil
+		}
+		if ctxt.OpenFile != nil {
+			return ctxt.OpenFile(name)
+		}
+		return os.Open(name)
+	}
+	copied.ReadDir = func(dir string) ([]fs.FileInfo, error) {
+		var infos []fs.FileInfo
+		var err error
+		infos, err = readDir(ctxt, dir)
+
+		// The files of the overlay replace those on disk.
+		var byName map[string]fs.FileInfo
+		byName = make(map[string]fs.FileInfo)
+		var info fs.FileInfo
+		for _, info = range infos {
+			byName[info.Name()] = info
+		}
+		var added bool
+		var name string
+		var content []byte
+		for name, content = range files {
+			if filepath.Dir(name) == absPath(dir) {
+				byName[filepath.Base(name)] = overlayInfo{name: filepath.Base(name), size: int64(len(content))}
+				added = true
+			}
+		}
+		if err != nil && !added {
+			return nil, err
+		}
+
+		infos = nil
+		for _, info = range byName {
+			infos = append(infos, info)
+		}
+		sort.Slice(infos, func(i, j int) bool {
+			return infos[i].Name() < infos[j].Name()
+		})
+		return infos, nil
+	}
+	return &copied
+}
+
+// readDir reads dir with ctxt, honouring its ReadDir hook.
+func readDir(ctxt *build.Context, dir string) ([]fs.FileInfo, error) {
+	if ctxt.ReadDir != nil {
+		return ctxt.ReadDir(dir)
+	}
+	var entries []os.DirEntry
+	var err error
+	entries, err = os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var infos []fs.FileInfo
+	var entry os.DirEntry
+	for _, entry = range entries {
+		var info fs.FileInfo
+		info, err = entry.Info()
+		if err == nil {
+			infos = append(infos, info)
+		}
+	}
+	return infos, nil
+}
+
+// ReadFile reads the file name with ctxt, honouring its OpenFile hook
+// and so the overlay of a context returned by Overlay.
+func ReadFile(ctxt *build.Context, name string) ([]byte, error) {
+	if ctxt.OpenFile == nil {
+		return os.ReadFile(name)
+	}
+	var f io.ReadCloser
+	var err error
+	f, err = ctxt.OpenFile(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+func absPath(name string) string {
+	var abs string
+	var err error
+	abs, err = filepath.Abs(name)
+	if err != nil {
+		return filepath.Clean(name)
+	}
+	return abs
+}
+
+// overlayInfo describes a file of an overlay.
+type overlayInfo struct {
+	name string
+	size int64
+}
+
+func (i overlayInfo) Name() string       { return i.name }
+func (i overlayInfo) Size() int64        { return i.size }
+func (i overlayInfo) Mode() fs.FileMode  { return 0o644 }
+func (i overlayInfo) ModTime() time.Time { return time.Time{} }
+func (i overlayInfo) IsDir() bool        { return false }
+func (i overlayInfo) Sys() interface{}   { return nil }
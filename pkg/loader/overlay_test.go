@@ -0,0 +1,56 @@
+package loader
+
+import (
+	"go/build"
+	"go/types"
+	"path/filepath"
+	"testing"
+)
+
+func TestOverlay(t *testing.T) {
+	t.Setenv("GOWORK", "off")
+
+	var root string
+	root = writeTree(t, map[string]string{
+		"m/go.mod":   "module example.com/m\n\ngo 1.22\n",
+		"m/a/a.go":   "package a\n\nimport \"example.com/m/b\"\n\nvar A = b.B\n",
+		"m/b/b.go":   "package b\n\nconst B = 1\n",
+		"m/b/old.go": "package b\n\nconst Old = 1\n",
+	})
+
+	// The overlay changes a file, and adds another.
+	var ctxt *build.Context
+	ctxt = Overlay(&build.Default, map[string][]byte{
+		filepath.Join(root, "m/b/b.go"): []byte("package b\n\nconst B = \"b\"\n"),
+		filepath.Join(root, "m/b/c.go"): []byte("package b\n\nconst C = 2\n"),
+	})
+
+	var imp *Importer
+	imp = NewImporter(ctxt)
+	var pkg *types.Package
+	var err error
+	pkg, err = imp.ImportFrom("example.com/m/a", filepath.Join(root, "m"), 0)
+	if err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+	var a types.Object
+	a = pkg.Scope().Lookup("A")
+	if a == nil || a.Type().String() != "string" {
+		t.Errorf("Expected A of type string, got %v", a)
+	}
+
+	var b *types.Package
+	b = pkg.Imports()[0]
+	var name string
+	for _, name = range []string{"B", "C", "Old"} {
+		if b.Scope().Lookup(name) == nil {
+			t.Errorf("Expected %s in package b", name)
+		}
+	}
+
+	var src []byte
+	src, err = ReadFile(ctxt, filepath.Join(root, "m/b/old.go"))
+	if err != nil || string(src) != "package b\n\nconst Old = 1\n" {
+		t.Errorf("Expected the file on disk, got %q, %v", src, err)
+	}
+}
@@ -0,0 +1,73 @@
+package report
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+// Checkstyle is the XML format of Checkstyle: the files with issues, and
+// an error per issue, its source the rule.
+type Checkstyle struct{}
+
+type checkstyleOutput struct {
+	XMLName xml.Name         `xml:"checkstyle"`
+	Version string           `xml:"version,attr"`
+	Files   []checkstyleFile `xml:"file"`
+}
+
+type checkstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []checkstyleError `xml:"error"`
+}
+
+type checkstyleError struct {
+	Line     int    `xml:"line,attr"`
+	Column   int    `xml:"column,attr"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+func (f *Checkstyle) Format(w io.Writer, r *Report) error {
+	var out checkstyleOutput
+	out.Version = "5.0"
+	var byName map[string]int
+	byName = make(map[string]int)
+	var i int
+	for i = range r.Issues {
+		var index int
+		var ok bool
+		index, ok = byName[r.Issues[i].File]
+		if !ok {
+			index = len(out.Files)
+			byName[r.Issues[i].File] = index
+			out.Files = append(out.Files, checkstyleFile{Name: r.Issues[i].File})
+		}
+		out.Files[index].Errors = append(out.Files[index].Errors, checkstyleError{
+			Line:     r.Issues[i].Line,
+			Column:   r.Issues[i].Column,
+			Severity: "error",
+			Message:  r.Issues[i].Message,
+			Source:   r.Issues[i].Rule,
+		})
+	}
+	return writeXML(w, out)
+}
+
+// writeXML writes v as an indented XML document.
+func writeXML(w io.Writer, v interface{}) error {
+	var err error
+	_, err = io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+	var enc *xml.Encoder
+	enc = xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	err = enc.Encode(v)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,181 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/thierry-f-78/go-syntax/pkg/types"
+)
+
+func TestGitHub(t *testing.T) {
+	var r *Report
+	r = testReport()
+	r.Issues = append(r.Issues, types.Issue{File: "dir,1/c.go", Message: "100% bad\nreally", Rule: "config"})
+
+	var out bytes.Buffer
+	var err error
+	err = (&GitHub{}).Format(&out, r)
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+	var expected string
+	expected = "::error file=a.go,line=4,col=2,title=[short-var-decl]::Short variable declaration ':=' is not allowed\n" +
+		"::error file=b.go,line=7,col=1,title=[named-returns]::Named return values are not allowed\n" +
+		"::error file=dir%2C1/c.go,title=[config]::100%25 bad%0Areally\n"
+	if out.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+// gitlabFingerprints returns the fingerprints of the Code Quality report
+// of issues.
+func gitlabFingerprints(t *testing.T, issues []types.Issue) []string {
+	var out bytes.Buffer
+	var err error
+	err = (&GitLab{}).Format(&out, &Report{Issues: issues})
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+	var decoded []gitlabIssue
+	err = json.Unmarshal(out.Bytes(), &decoded)
+	if err != nil {
+		t.Fatalf("Invalid JSON: %v\n%s", err, out.String())
+	}
+	var fingerprints []string
+	var issue gitlabIssue
+	for _, issue = range decoded {
+		fingerprints = append(fingerprints, issue.Fingerprint)
+	}
+	return fingerprints
+}
+
+func TestGitLab(t *testing.T) {
+	var dir string
+	dir = t.TempDir()
+	var filename string
+	filename = filepath.Join(dir, "a.go")
+	var err error
+	err = os.WriteFile(filename, []byte("package a\n\nfunc f() {\n\tx := 1\n\tx := 1\n}\n"), 0o644)
+	if err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	var issue types.Issue
+	issue = types.Issue{File: filename, Line: 4, Column: 2, Message: "Short variable declaration", Rule: "short-var-decl"}
+	var second types.Issue = issue
+	second.Line = 5
+	var before []string
+	before = gitlabFingerprints(t, []types.Issue{issue, second})
+	if before[0] == before[1] {
+		t.Errorf("Expected distinct fingerprints for identical issues, got %s twice", before[0])
+	}
+
+	// A line added above the issues does not change their fingerprints.
+	err = os.WriteFile(filename, []byte("package a\n\n// f does nothing.\nfunc f() {\n\tx := 1\n\tx := 1\n}\n"), 0o644)
+	if err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	issue.Line++
+	second.Line++
+	var after []string
+	after = gitlabFingerprints(t, []types.Issue{issue, second})
+	if after[0] != before[0] || after[1] != before[1] {
+		t.Errorf("Expected the fingerprints %v, got %v", before, after)
+	}
+
+	// An identical issue added below, the report sorted from the bottom,
+	// does not change them either.
+	err = os.WriteFile(filename, []byte("package a\n\n// f does nothing.\nfunc f() {\n\tx := 1\n\tx := 1\n\tx := 1\n}\n"), 0o644)
+	if err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	var third types.Issue = second
+	third.Line++
+	var added []string
+	added = gitlabFingerprints(t, []types.Issue{third, second, issue})
+	if added[2] != before[0] || added[1] != before[1] {
+		t.Errorf("Expected the fingerprints %v, got %v", before, added[1:])
+	}
+
+	// The source is read by the ReadFile of the report, such as the
+	// standard input, rather than from disk.
+	var out bytes.Buffer
+	err = (&GitLab{}).Format(&out, &Report{
+		Issues: []types.Issue{issue},
+		ReadFile: func(name string) ([]byte, error) {
+			return []byte("package a\n\n// f does nothing.\nfunc f() {\n\ty := 1\n}\n"), nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+	var read []gitlabIssue
+	err = json.Unmarshal(out.Bytes(), &read)
+	if err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if read[0].Fingerprint == before[0] {
+		t.Errorf("Expected the fingerprint of the line read by ReadFile, got that of the file on disk")
+	}
+
+	out.Reset()
+	err = (&GitLab{}).Format(&out, &Report{Issues: []types.Issue{{File: "dir/b.go", Line: 3, Message: "Bad", Rule: "if-init"}}})
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+	var decoded []gitlabIssue
+	err = json.Unmarshal(out.Bytes(), &decoded)
+	if err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if decoded[0].CheckName != "if-init" || decoded[0].Location.Path != "dir/b.go" || decoded[0].Location.Lines.Begin != 3 || decoded[0].Severity != "major" {
+		t.Errorf("Unexpected issue %+v", decoded[0])
+	}
+
+	// An empty report is an empty list.
+	out.Reset()
+	err = (&GitLab{}).Format(&out, &Report{})
+	if err != nil || out.String() != "[]\n" {
+		t.Errorf("Expected an empty list, got %q, %v", out.String(), err)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	var tests []struct {
+		name     string
+		github   string
+		gitlab   string
+		expected string
+	}
+	tests = []struct {
+		name     string
+		github   string
+		gitlab   string
+		expected string
+	}{
+		{name: "terminal", expected: "text"},
+		{name: "github actions", github: "true", expected: "github"},
+		{name: "gitlab ci", gitlab: "true", expected: "gitlab"},
+	}
+
+	var tt struct {
+		name     string
+		github   string
+		gitlab   string
+		expected string
+	}
+	for _, tt = range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GITHUB_ACTIONS", tt.github)
+			t.Setenv("GITLAB_CI", tt.gitlab)
+			var got string
+			got = Detect()
+			if got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// GitHub is the format of the workflow commands of GitHub Actions, which
+// annotate the lines of the issues:
+//
+//	::error file=a.go,line=4,col=2,title=[rule]::message
+type GitHub struct{}
+
+func (f *GitHub) Format(w io.Writer, r *Report) error {
+	var i int
+	for i = range r.Issues {
+		var properties []string
+		properties = append(properties, "file="+escapeGitHubProperty(r.Issues[i].File))
+		if r.Issues[i].Line > 0 {
+			properties = append(properties,
+				fmt.Sprintf("line=%d", r.Issues[i].Line),
+				fmt.Sprintf("col=%d", r.Issues[i].Column),
+			)
+		}
+		properties = append(properties, "title="+escapeGitHubProperty("["+r.Issues[i].Rule+"]"))
+
+		var err error
+		_, err = fmt.Fprintf(w, "::error %s::%s\n", strings.Join(properties, ","), escapeGitHubData(r.Issues[i].Message))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escapeGitHubData escapes the message of a workflow command.
+func escapeGitHubData(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	return strings.ReplaceAll(s, "\n", "%0A")
+}
+
+// escapeGitHubProperty escapes the value of a property of a workflow
+// command.
+func escapeGitHubProperty(s string) string {
+	s = escapeGitHubData(s)
+	s = strings.ReplaceAll(s, ":", "%3A")
+	return strings.ReplaceAll(s, ",", "%2C")
+}
@@ -0,0 +1,122 @@
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/thierry-f-78/go-syntax/pkg/types"
+)
+
+// GitLab is the Code Quality report of GitLab, which shows the issues in
+// the merge requests. The paths are relative to the current directory,
+// the root of the project in the CI jobs.
+//
+// The fingerprints identify the issues across the commits: they hash the
+// file, the rule, the message and the source line of the issue rather
+// than its line number, so that an issue keeps its fingerprint when the
+// lines above it change.
+type GitLab struct{}
+
+type gitlabIssue struct {
+	Type        string         `json:"type"`
+	CheckName   string         `json:"check_name"`
+	Description string         `json:"description"`
+	Categories  []string       `json:"categories"`
+	Severity    string         `json:"severity"`
+	Fingerprint string         `json:"fingerprint"`
+	Location    gitlabLocation `json:"location"`
+}
+
+type gitlabLocation struct {
+	Path  string      `json:"path"`
+	Lines gitlabLines `json:"lines"`
+}
+
+type gitlabLines struct {
+	Begin int `json:"begin"`
+}
+
+func (f *GitLab) Format(w io.Writer, r *Report) error {
+	var wd string
+	wd, _ = os.Getwd()
+
+	var src *sources
+	src = newSources(r)
+
+	var paths []string
+	var keys []string
+	var i int
+	for i = range r.Issues {
+		var path string
+		path = r.Issues[i].File
+		if filepath.IsAbs(path) && wd != "" {
+			var rel string
+			var err error
+			rel, err = filepath.Rel(wd, path)
+			if err == nil && !strings.HasPrefix(rel, "..") {
+				path = rel
+			}
+		}
+		paths = append(paths, filepath.ToSlash(path))
+		keys = append(keys, fmt.Sprintf("%s\x00%s\x00%s\x00%s", paths[i], r.Issues[i].Rule, r.Issues[i].Message,
+			strings.TrimSpace(src.line(r.Issues[i].File, r.Issues[i].Line))))
+	}
+
+	// The identical issues of a file are told apart by their rank, from
+	// the top of the file whatever the order of the report, so that an
+	// issue added below them leaves their fingerprints as they are.
+	var byPosition []int
+	for i = range r.Issues {
+		byPosition = append(byPosition, i)
+	}
+	sort.SliceStable(byPosition, func(a, b int) bool {
+		var x types.Issue = r.Issues[byPosition[a]]
+		var y types.Issue = r.Issues[byPosition[b]]
+		if x.Line != y.Line {
+			return x.Line < y.Line
+		}
+		return x.Column < y.Column
+	})
+	var ranks []int
+	ranks = make([]int, len(r.Issues))
+	var seen map[string]int
+	seen = make(map[string]int)
+	for _, i = range byPosition {
+		ranks[i] = seen[keys[i]]
+		seen[keys[i]]++
+	}
+
+	var out []gitlabIssue
+	out = []gitlabIssue{}
+	for i = range r.Issues {
+		var h hash.Hash
+		h = sha256.New()
+		fmt.Fprintf(h, "%s\x00%d", keys[i], ranks[i])
+
+		var line int = r.Issues[i].Line
+		if line < 1 {
+			line = 1
+		}
+		out = append(out, gitlabIssue{
+			Type:        "issue",
+			CheckName:   r.Issues[i].Rule,
+			Description: fmt.Sprintf("[%s] %s", r.Issues[i].Rule, r.Issues[i].Message),
+			Categories:  []string{"Style"},
+			Severity:    "major",
+			Fingerprint: fmt.Sprintf("%x", h.Sum(nil)),
+			Location:    gitlabLocation{Path: paths[i], Lines: gitlabLines{Begin: line}},
+		})
+	}
+
+	var enc *json.Encoder
+	enc = json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
@@ -0,0 +1,159 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/thierry-f-78/go-syntax/pkg/types"
+)
+
+// SchemaVersion is the version of the schema of the JSON reports. It
+// changes when a field is removed or changes meaning, not when one is
+// added.
+const SchemaVersion int = 1
+
+// JSON is the format of the whole report as a JSON object.
+type JSON struct{}
+
+// JSONLines is the format of the issues as JSON objects, one per line, as
+// those of the JSON report along with its schema_version and tool, so that
+// each line stands alone. The lines are written once the run is over, as
+// for the other formats, not as the issues are found.
+type JSONLines struct{}
+
+type jsonReport struct {
+	SchemaVersion int         `json:"schema_version"`
+	Tool          jsonTool    `json:"tool"`
+	Files         []string    `json:"files"`
+	Rules         []jsonRule  `json:"rules"`
+	Issues        []jsonIssue `json:"issues"`
+}
+
+type jsonTool struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type jsonRule struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Category    string       `json:"category"`
+	Enabled     bool         `json:"enabled"`
+	Options     []jsonOption `json:"options,omitempty"`
+}
+
+type jsonOption struct {
+	Name        string      `json:"name"`
+	Type        string      `json:"type"`
+	Default     interface{} `json:"default"`
+	Description string      `json:"description"`
+}
+
+type jsonIssue struct {
+	File        string   `json:"file"`
+	Line        int      `json:"line"`
+	Column      int      `json:"column"`
+	Rule        string   `json:"rule"`
+	Message     string   `json:"message"`
+	Description string   `json:"description,omitempty"`
+	Fix         *jsonFix `json:"fix,omitempty"`
+	NoFixReason string   `json:"no_fix_reason,omitempty"`
+	Suppressed  bool     `json:"suppressed,omitempty"`
+}
+
+// jsonLine is an issue of the JSON Lines format.
+type jsonLine struct {
+	SchemaVersion int      `json:"schema_version"`
+	Tool          jsonTool `json:"tool"`
+	jsonIssue
+}
+
+type jsonFix struct {
+	Description string     `json:"description"`
+	Edits       []jsonEdit `json:"edits"`
+}
+
+type jsonEdit struct {
+	Start   int    `json:"start"`
+	End     int    `json:"end"`
+	NewText string `json:"new_text"`
+}
+
+func (f *JSON) Format(w io.Writer, r *Report) error {
+	var out jsonReport
+	out = jsonReport{
+		SchemaVersion: SchemaVersion,
+		Tool:          jsonTool{Name: "go-syntax", Version: r.Version},
+		Files:         append([]string{}, r.Files...),
+		Rules:         []jsonRule{},
+		Issues:        []jsonIssue{},
+	}
+	var i int
+	for i = range r.Rules {
+		var rule jsonRule
+		rule = jsonRule{
+			Name:        r.Rules[i].Name,
+			Description: r.Rules[i].Description,
+			Category:    r.Rules[i].Category,
+			Enabled:     r.Rules[i].Enabled,
+		}
+		var j int
+		for j = range r.Rules[i].Options {
+			rule.Options = append(rule.Options, jsonOption{
+				Name:        r.Rules[i].Options[j].Name,
+				Type:        r.Rules[i].Options[j].Type,
+				Default:     r.Rules[i].Options[j].Default,
+				Description: r.Rules[i].Options[j].Description,
+			})
+		}
+		out.Rules = append(out.Rules, rule)
+	}
+	for i = range r.Issues {
+		out.Issues = append(out.Issues, newJSONIssue(r.Issues[i]))
+	}
+
+	var enc *json.Encoder
+	enc = json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+func (f *JSONLines) Format(w io.Writer, r *Report) error {
+	var enc *json.Encoder
+	enc = json.NewEncoder(w)
+	var i int
+	for i = range r.Issues {
+		var err error
+		err = enc.Encode(jsonLine{
+			SchemaVersion: SchemaVersion,
+			Tool:          jsonTool{Name: "go-syntax", Version: r.Version},
+			jsonIssue:     newJSONIssue(r.Issues[i]),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newJSONIssue(issue types.Issue) jsonIssue {
+	var out jsonIssue
+	out = jsonIssue{
+		File:        issue.File,
+		Line:        issue.Line,
+		Column:      issue.Column,
+		Rule:        issue.Rule,
+		Message:     issue.Message,
+		Description: issue.Description,
+		NoFixReason: issue.NoFixReason,
+		Suppressed:  issue.Suppressed,
+	}
+	if issue.Fix != nil {
+		out.Fix = &jsonFix{Description: issue.Fix.Description, Edits: []jsonEdit{}}
+		var edit types.TextEdit
+		for _, edit = range issue.Fix.Edits {
+			out.Fix.Edits = append(out.Fix.Edits, jsonEdit{Start: edit.Start, End: edit.End, NewText: edit.NewText})
+		}
+	}
+	return out
+}
@@ -0,0 +1,110 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+)
+
+// JUnit is the XML format of the JUnit test reports: a test suite per
+// package, a test case per file, and a failure per issue.
+type JUnit struct{}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string         `xml:"name,attr"`
+	ClassName string         `xml:"classname,attr"`
+	Failures  []junitFailure `xml:"failure"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// junitIndex locates a test case in its test suite.
+type junitIndex struct {
+	suite int
+	test  int
+}
+
+func (f *JUnit) Format(w io.Writer, r *Report) error {
+	var out junitTestSuites
+	out.Name = "go-syntax"
+
+	// The files analysed pass, unless they have issues; the issues out of
+	// them, such as those of the configuration files, fail files of their
+	// own.
+	var suites map[string]int
+	suites = make(map[string]int)
+	var cases map[string]junitIndex
+	cases = make(map[string]junitIndex)
+	var addFile func(file string) junitIndex
+	addFile = func(file string) junitIndex {
+		var index junitIndex
+		var ok bool
+		index, ok = cases[file]
+		if ok {
+			return index
+		}
+		var pkg string
+		pkg = filepath.ToSlash(filepath.Dir(file))
+		index.suite, ok = suites[pkg]
+		if !ok {
+			index.suite = len(out.Suites)
+			suites[pkg] = index.suite
+			out.Suites = append(out.Suites, junitTestSuite{Name: pkg})
+		}
+		index.test = len(out.Suites[index.suite].Cases)
+		out.Suites[index.suite].Cases = append(out.Suites[index.suite].Cases, junitTestCase{
+			Name:      filepath.Base(file),
+			ClassName: pkg,
+		})
+		out.Suites[index.suite].Tests++
+		out.Tests++
+		cases[file] = index
+		return index
+	}
+
+	var file string
+	for _, file = range r.Files {
+		addFile(file)
+	}
+	var i int
+	for i = range r.Issues {
+		var index junitIndex
+		index = addFile(r.Issues[i].File)
+		var testCase *junitTestCase
+		testCase = &out.Suites[index.suite].Cases[index.test]
+		if len(testCase.Failures) == 0 {
+			out.Suites[index.suite].Failures++
+			out.Failures++
+		}
+		testCase.Failures = append(testCase.Failures, junitFailure{
+			Message: r.Issues[i].Message,
+			Type:    r.Issues[i].Rule,
+			Text: fmt.Sprintf("%s:%d:%d: [%s] %s\n%s",
+				r.Issues[i].File, r.Issues[i].Line, r.Issues[i].Column,
+				r.Issues[i].Rule, r.Issues[i].Message, r.Issues[i].Description,
+			),
+		})
+	}
+	return writeXML(w, out)
+}
@@ -0,0 +1,150 @@
+// Package report writes the issues found by go-syntax in the formats of
+// the -format flag: the text read by people, and the formats read by
+// programs.
+package report
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/thierry-f-78/go-syntax/pkg/rules"
+	"github.com/thierry-f-78/go-syntax/pkg/types"
+)
+
+// Report is the outcome of a run of go-syntax.
+type Report struct {
+	// Version is the version of go-syntax.
+	Version string
+	// Files are the files analysed.
+	Files []string
+	// Rules describe the rules go-syntax knows.
+	Rules []rules.Info
+	// Issues are the issues found, in the order they are written. They
+	// include those silenced by a //nolint comment for the formatters
+	// NeedsSuppressed tells so.
+	Issues []types.Issue
+	// ReadFile reads the files of the issues, for the formats quoting
+	// their source, as the linter read them: from the standard input or
+	// an overlay rather than the disk. os.ReadFile when nil.
+	ReadFile func(name string) ([]byte, error)
+}
+
+// Formatter writes reports in a format.
+type Formatter interface {
+	Format(w io.Writer, r *Report) error
+}
+
+// Options configures the formatters.
+type Options struct {
+	// Color colors the text output.
+	Color bool
+	// Verbose adds the descriptions of the issues and a summary to the
+	// text output.
+	Verbose bool
+	// Template is the template of the template format.
+	Template string
+}
+
+// formats are the formatters by name.
+var formats map[string]func(options Options) Formatter = map[string]func(options Options) Formatter{
+	"text": func(options Options) Formatter {
+		return &Text{Color: options.Color, Verbose: options.Verbose}
+	},
+	"json": func(options Options) Formatter {
+		return &JSON{}
+	},
+	"jsonl": func(options Options) Formatter {
+		return &JSONLines{}
+	},
+	"sarif": func(options Options) Formatter {
+		return &SARIF{}
+	},
+	"checkstyle": func(options Options) Formatter {
+		return &Checkstyle{}
+	},
+	"junit": func(options Options) Formatter {
+		return &JUnit{}
+	},
+	"github": func(options Options) Formatter {
+		return &GitHub{}
+	},
+	"gitlab": func(options Options) Formatter {
+		return &GitLab{}
+	},
+}
+
+// New returns the formatter of the format name. The template format
+// takes its template from options, or from name as template=text.
+func New(name string, options Options) (Formatter, error) {
+	if name == "template" || strings.HasPrefix(name, "template=") {
+		var text string = options.Template
+		if name != "template" {
+			text = strings.TrimPrefix(name, "template=")
+		}
+		if text == "" {
+			return nil, fmt.Errorf("the template format needs a template")
+		}
+		var tmpl *Template
+		var err error
+		tmpl, err = NewTemplate(text)
+		if err != nil {
+			return nil, err
+		}
+		return tmpl, nil
+	}
+
+	var newFormatter func(options Options) Formatter
+	var ok bool
+	newFormatter, ok = formats[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown format %q", name)
+	}
+	return newFormatter(options), nil
+}
+
+// Detect returns the format of the CI environment the command runs in:
+// github in GitHub Actions, gitlab in GitLab CI, text otherwise.
+func Detect() string {
+	if os.Getenv("GITHUB_ACTIONS") == "true" {
+		return "github"
+	}
+	if os.Getenv("GITLAB_CI") == "true" {
+		return "gitlab"
+	}
+	return "text"
+}
+
+// NeedsFixes tells whether f writes the suggested fixes of the issues,
+// which are costly to compute.
+func NeedsFixes(f Formatter) bool {
+	switch f.(type) {
+	case *SARIF:
+		return true
+	}
+	return false
+}
+
+// NeedsSuppressed tells whether f records the issues silenced by a
+// //nolint comment.
+func NeedsSuppressed(f Formatter) bool {
+	switch f.(type) {
+	case *SARIF:
+		return true
+	}
+	return false
+}
+
+// Names returns the names of the formats, sorted.
+func Names() []string {
+	var names []string
+	var name string
+	for name = range formats {
+		names = append(names, name)
+	}
+	names = append(names, "template")
+	sort.Strings(names)
+	return names
+}
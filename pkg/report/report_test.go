@@ -0,0 +1,230 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/thierry-f-78/go-syntax/pkg/rules"
+	"github.com/thierry-f-78/go-syntax/pkg/types"
+)
+
+// testReport returns a report of two issues, the first one fixed.
+func testReport() *Report {
+	return &Report{
+		Version: "v1.2.3",
+		Files:   []string{"a.go", "b.go"},
+		Rules: []rules.Info{{
+			Name:        "short-var-decl",
+			Description: "Short variable declarations",
+			Category:    "declarations",
+			Enabled:     true,
+			Options:     []rules.OptionInfo{{Name: "allow-range", Type: "bool", Default: false, Description: "Accept range"}},
+		}},
+		Issues: []types.Issue{
+			{
+				File:        "a.go",
+				Line:        4,
+				Column:      2,
+				Message:     "Short variable declaration ':=' is not allowed",
+				Description: "Avoid ':='",
+				Rule:        "short-var-decl",
+				Fix: &types.SuggestedFix{
+					Description: "Declare x with var",
+					Edits:       []types.TextEdit{{Start: 20, End: 26, NewText: "var x int\n\tx = 1"}},
+				},
+			},
+			{
+				File:        "b.go",
+				Line:        7,
+				Column:      1,
+				Message:     "Named return values are not allowed",
+				Rule:        "named-returns",
+				NoFixReason: "the results are used by name",
+			},
+		},
+	}
+}
+
+func TestText(t *testing.T) {
+	var tests []struct {
+		name     string
+		options  Options
+		expected string
+	}
+	tests = []struct {
+		name     string
+		options  Options
+		expected string
+	}{
+		{
+			name: "plain",
+			expected: "a.go:4:2: [short-var-decl] Short variable declaration ':=' is not allowed\n" +
+				"b.go:7:1: [named-returns] Named return values are not allowed\n",
+		},
+		{
+			name:    "verbose",
+			options: Options{Verbose: true},
+			expected: "a.go:4:2: [short-var-decl] Short variable declaration ':=' is not allowed\n" +
+				"  Avoid ':='\n\n" +
+				"b.go:7:1: [named-returns] Named return values are not allowed\n" +
+				"  \n\n" +
+				"Analyzed 2 files\n",
+		},
+		{
+			name:    "color",
+			options: Options{Color: true},
+			expected: "\033[31ma.go:4:2: [short-var-decl] Short variable declaration ':=' is not allowed\033[0m\n" +
+				"\033[31mb.go:7:1: [named-returns] Named return values are not allowed\033[0m\n",
+		},
+	}
+
+	var tt struct {
+		name     string
+		options  Options
+		expected string
+	}
+	for _, tt = range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var f Formatter
+			var err error
+			f, err = New("text", tt.options)
+			if err != nil {
+				t.Fatalf("Failed to create formatter: %v", err)
+			}
+			var out bytes.Buffer
+			err = f.Format(&out, testReport())
+			if err != nil {
+				t.Fatalf("Failed to format: %v", err)
+			}
+			if out.String() != tt.expected {
+				t.Errorf("Expected:\n%q\ngot:\n%q", tt.expected, out.String())
+			}
+		})
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var out bytes.Buffer
+	var err error
+	err = (&JSON{}).Format(&out, testReport())
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+
+	var decoded map[string]interface{}
+	err = json.Unmarshal(out.Bytes(), &decoded)
+	if err != nil {
+		t.Fatalf("Invalid JSON: %v\n%s", err, out.String())
+	}
+	var expected string
+	expected = `{
+  "schema_version": 1,
+  "tool": {
+    "name": "go-syntax",
+    "version": "v1.2.3"
+  },
+  "files": [
+    "a.go",
+    "b.go"
+  ],
+  "rules": [
+    {
+      "name": "short-var-decl",
+      "description": "Short variable declarations",
+      "category": "declarations",
+      "enabled": true,
+      "options": [
+        {
+          "name": "allow-range",
+          "type": "bool",
+          "default": false,
+          "description": "Accept range"
+        }
+      ]
+    }
+  ],
+  "issues": [
+    {
+      "file": "a.go",
+      "line": 4,
+      "column": 2,
+      "rule": "short-var-decl",
+      "message": "Short variable declaration ':=' is not allowed",
+      "description": "Avoid ':='",
+      "fix": {
+        "description": "Declare x with var",
+        "edits": [
+          {
+            "start": 20,
+            "end": 26,
+            "new_text": "var x int\n\tx = 1"
+          }
+        ]
+      }
+    },
+    {
+      "file": "b.go",
+      "line": 7,
+      "column": 1,
+      "rule": "named-returns",
+      "message": "Named return values are not allowed",
+      "no_fix_reason": "the results are used by name"
+    }
+  ]
+}
+`
+	if out.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+
+	// An empty report has empty lists rather than nulls.
+	out.Reset()
+	err = (&JSON{}).Format(&out, &Report{})
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+	if !strings.Contains(out.String(), `"issues": []`) || !strings.Contains(out.String(), `"files": []`) {
+		t.Errorf("Expected empty lists, got:\n%s", out.String())
+	}
+}
+
+func TestJSONLines(t *testing.T) {
+	var out bytes.Buffer
+	var err error
+	err = (&JSONLines{}).Format(&out, testReport())
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+
+	var lines []string
+	lines = strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d:\n%s", len(lines), out.String())
+	}
+	var expected []string
+	expected = []string{"a.go", "b.go"}
+	var i int
+	for i = range lines {
+		var issue jsonLine
+		err = json.Unmarshal([]byte(lines[i]), &issue)
+		if err != nil {
+			t.Fatalf("Invalid JSON line %q: %v", lines[i], err)
+		}
+		if issue.File != expected[i] {
+			t.Errorf("Expected the issue of %s on line %d, got %s", expected[i], i+1, issue.File)
+		}
+		if issue.SchemaVersion != SchemaVersion || issue.Tool.Version != "v1.2.3" {
+			t.Errorf("Expected the schema and tool versions on line %d, got %q", i+1, lines[i])
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	var err error
+	_, err = New("xml", Options{})
+	if err == nil || err.Error() != `unknown format "xml"` {
+		t.Errorf("Expected an unknown format error, got %v", err)
+	}
+}
@@ -0,0 +1,223 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/thierry-f-78/go-syntax/pkg/types"
+)
+
+// sarifSchema is the JSON schema of the SARIF logs.
+const sarifSchema string = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// informationURI is the home page of go-syntax.
+const informationURI string = "https://github.com/thierry-f-78/go-syntax"
+
+// SARIF is the format of the code scanning tools, SARIF 2.1.0. The issues
+// silenced by a //nolint comment are recorded with an in-source
+// suppression, and the suggested fixes as fixes.
+type SARIF struct{}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string              `json:"id"`
+	ShortDescription     *sarifMessage       `json:"shortDescription,omitempty"`
+	FullDescription      *sarifMessage       `json:"fullDescription,omitempty"`
+	DefaultConfiguration *sarifConfiguration `json:"defaultConfiguration,omitempty"`
+	Properties           *sarifProperties    `json:"properties,omitempty"`
+}
+
+type sarifConfiguration struct {
+	Enabled bool   `json:"enabled"`
+	Level   string `json:"level"`
+}
+
+type sarifProperties struct {
+	Tags []string `json:"tags"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID       string             `json:"ruleId"`
+	RuleIndex    int                `json:"ruleIndex"`
+	Level        string             `json:"level"`
+	Message      sarifMessage       `json:"message"`
+	Locations    []sarifLocation    `json:"locations"`
+	Suppressions []sarifSuppression `json:"suppressions,omitempty"`
+	Fixes        []sarifFix         `json:"fixes,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// sarifRegion is either a line and column, or a range of bytes.
+type sarifRegion struct {
+	StartLine   int  `json:"startLine,omitempty"`
+	StartColumn int  `json:"startColumn,omitempty"`
+	ByteOffset  *int `json:"byteOffset,omitempty"`
+	ByteLength  *int `json:"byteLength,omitempty"`
+}
+
+type sarifSuppression struct {
+	Kind string `json:"kind"`
+}
+
+type sarifFix struct {
+	Description     sarifMessage          `json:"description"`
+	ArtifactChanges []sarifArtifactChange `json:"artifactChanges"`
+}
+
+type sarifArtifactChange struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Replacements     []sarifReplacement    `json:"replacements"`
+}
+
+type sarifReplacement struct {
+	DeletedRegion   sarifRegion   `json:"deletedRegion"`
+	InsertedContent *sarifMessage `json:"insertedContent,omitempty"`
+}
+
+func (f *SARIF) Format(w io.Writer, r *Report) error {
+	var run sarifRun
+	run = sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "go-syntax",
+			Version:        r.Version,
+			InformationURI: informationURI,
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+
+	// The rules of the registry, then those only known by their issues,
+	// such as the parse errors.
+	var ruleIndex map[string]int
+	ruleIndex = make(map[string]int)
+	var i int
+	for i = range r.Rules {
+		ruleIndex[r.Rules[i].Name] = len(run.Tool.Driver.Rules)
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:                   r.Rules[i].Name,
+			ShortDescription:     &sarifMessage{Text: r.Rules[i].Description},
+			DefaultConfiguration: &sarifConfiguration{Enabled: r.Rules[i].Enabled, Level: "error"},
+			Properties:           &sarifProperties{Tags: []string{r.Rules[i].Category}},
+		})
+	}
+	for i = range r.Issues {
+		var index int
+		var ok bool
+		index, ok = ruleIndex[r.Issues[i].Rule]
+		if !ok {
+			index = len(run.Tool.Driver.Rules)
+			ruleIndex[r.Issues[i].Rule] = index
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: r.Issues[i].Rule})
+		}
+		// The descriptions of the issues are those of their rule.
+		if r.Issues[i].Description != "" && run.Tool.Driver.Rules[index].FullDescription == nil {
+			run.Tool.Driver.Rules[index].FullDescription = &sarifMessage{Text: r.Issues[i].Description}
+		}
+		run.Results = append(run.Results, newSARIFResult(r.Issues[i], index))
+	}
+
+	var enc *json.Encoder
+	enc = json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  sarifSchema,
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
+
+func newSARIFResult(issue types.Issue, ruleIndex int) sarifResult {
+	var location sarifLocation
+	location.PhysicalLocation.ArtifactLocation.URI = fileURI(issue.File)
+	// The configuration errors have no line.
+	if issue.Line > 0 {
+		location.PhysicalLocation.Region = &sarifRegion{StartLine: issue.Line, StartColumn: issue.Column}
+	}
+
+	var result sarifResult
+	result = sarifResult{
+		RuleID:    issue.Rule,
+		RuleIndex: ruleIndex,
+		Level:     "error",
+		Message:   sarifMessage{Text: issue.Message},
+		Locations: []sarifLocation{location},
+	}
+	if issue.Suppressed {
+		result.Suppressions = []sarifSuppression{{Kind: "inSource"}}
+	}
+	if issue.Fix != nil {
+		var change sarifArtifactChange
+		change = sarifArtifactChange{
+			ArtifactLocation: sarifArtifactLocation{URI: fileURI(issue.File)},
+			Replacements:     []sarifReplacement{},
+		}
+		var edit types.TextEdit
+		for _, edit = range issue.Fix.Edits {
+			var offset int = edit.Start
+			var length int = edit.End - edit.Start
+			var replacement sarifReplacement
+			replacement = sarifReplacement{DeletedRegion: sarifRegion{ByteOffset: &offset, ByteLength: &length}}
+			if edit.NewText != "" {
+				replacement.InsertedContent = &sarifMessage{Text: edit.NewText}
+			}
+			change.Replacements = append(change.Replacements, replacement)
+		}
+		result.Fixes = []sarifFix{{
+			Description:     sarifMessage{Text: issue.Fix.Description},
+			ArtifactChanges: []sarifArtifactChange{change},
+		}}
+	}
+	return result
+}
+
+// fileURI returns the URI of the file name: relative to the directory of
+// the analysis when name is relative, with the file scheme otherwise.
+func fileURI(name string) string {
+	var u url.URL
+	u.Path = filepath.ToSlash(name)
+	if filepath.IsAbs(name) {
+		u.Scheme = "file"
+		u.Path = "/" + strings.TrimPrefix(u.Path, "/")
+	}
+	return u.String()
+}
@@ -0,0 +1,104 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	"github.com/thierry-f-78/go-syntax/pkg/types"
+)
+
+func TestSARIF(t *testing.T) {
+	var r *Report
+	r = testReport()
+	var abs string
+	abs = filepath.Join(string(filepath.Separator), "src", "my module", "c.go")
+	r.Issues = append(r.Issues,
+		types.Issue{File: abs, Line: 3, Column: 5, Message: "Silenced", Rule: "short-var-decl", Suppressed: true},
+		types.Issue{File: ".go-syntax.yml", Message: "Configuration error", Rule: "config"},
+	)
+
+	var out bytes.Buffer
+	var err error
+	err = (&SARIF{}).Format(&out, r)
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+
+	var compiler *jsonschema.Compiler
+	compiler = jsonschema.NewCompiler()
+	var f *os.File
+	f, err = os.Open(filepath.Join("testdata", "sarif-schema-2.1.0.json"))
+	if err != nil {
+		t.Fatalf("Failed to open the schema: %v", err)
+	}
+	defer f.Close()
+	err = compiler.AddResource("sarif-schema-2.1.0.json", f)
+	if err != nil {
+		t.Fatalf("Failed to load the schema: %v", err)
+	}
+	var schema *jsonschema.Schema
+	schema, err = compiler.Compile("sarif-schema-2.1.0.json")
+	if err != nil {
+		t.Fatalf("Failed to compile the schema: %v", err)
+	}
+
+	var decoded interface{}
+	err = json.Unmarshal(out.Bytes(), &decoded)
+	if err != nil {
+		t.Fatalf("Invalid JSON: %v\n%s", err, out.String())
+	}
+	err = schema.Validate(decoded)
+	if err != nil {
+		t.Fatalf("Invalid SARIF log: %#v\n%s", err, out.String())
+	}
+
+	var log sarifLog
+	err = json.Unmarshal(out.Bytes(), &log)
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	var run sarifRun
+	run = log.Runs[0]
+
+	var ids []string
+	var rule sarifRule
+	for _, rule = range run.Tool.Driver.Rules {
+		ids = append(ids, rule.ID)
+	}
+	var expected []string
+	expected = []string{"short-var-decl", "named-returns", "config"}
+	if len(ids) != len(expected) || ids[0] != expected[0] || ids[1] != expected[1] || ids[2] != expected[2] {
+		t.Errorf("Expected rules %v, got %v", expected, ids)
+	}
+	if run.Tool.Driver.Rules[0].FullDescription == nil || run.Tool.Driver.Rules[0].FullDescription.Text != "Avoid ':='" {
+		t.Errorf("Expected the description of the issues, got %v", run.Tool.Driver.Rules[0].FullDescription)
+	}
+
+	if len(run.Results) != 4 {
+		t.Fatalf("Expected 4 results, got %d", len(run.Results))
+	}
+	var fix sarifFix
+	fix = run.Results[0].Fixes[0]
+	var region sarifRegion
+	region = fix.ArtifactChanges[0].Replacements[0].DeletedRegion
+	if *region.ByteOffset != 20 || *region.ByteLength != 6 {
+		t.Errorf("Expected the bytes [20, 26) replaced, got %d, %d", *region.ByteOffset, *region.ByteLength)
+	}
+	if run.Results[1].RuleIndex != 1 || len(run.Results[1].Fixes) != 0 {
+		t.Errorf("Expected a result of rule 1 without fix, got %+v", run.Results[1])
+	}
+	if len(run.Results[2].Suppressions) != 1 || run.Results[2].Suppressions[0].Kind != "inSource" {
+		t.Errorf("Expected an in-source suppression, got %+v", run.Results[2].Suppressions)
+	}
+	if run.Results[2].Locations[0].PhysicalLocation.ArtifactLocation.URI != "file:///src/my%20module/c.go" {
+		t.Errorf("Expected a file URI, got %s", run.Results[2].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	}
+	if run.Results[3].Locations[0].PhysicalLocation.Region != nil || run.Results[3].RuleIndex != 2 {
+		t.Errorf("Expected a result of rule 2 without region, got %+v", run.Results[3])
+	}
+}
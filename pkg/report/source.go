@@ -0,0 +1,43 @@
+package report
+
+import (
+	"os"
+	"strings"
+)
+
+// sources are the lines of the files of the issues, read once per file.
+type sources struct {
+	readFile func(name string) ([]byte, error)
+	lines    map[string][]string
+}
+
+// newSources returns the sources of the files of r, read by its ReadFile.
+func newSources(r *Report) *sources {
+	var s *sources
+	s = &sources{readFile: os.ReadFile, lines: make(map[string][]string)}
+	if r != nil && r.ReadFile != nil {
+		s.readFile = r.ReadFile
+	}
+	return s
+}
+
+// line returns the line of file numbered from 1, without its end of line,
+// or the empty string when it cannot be read.
+func (s *sources) line(file string, line int) string {
+	var lines []string
+	var ok bool
+	lines, ok = s.lines[file]
+	if !ok {
+		var data []byte
+		var err error
+		data, err = s.readFile(file)
+		if err == nil {
+			lines = strings.Split(string(data), "\n")
+		}
+		s.lines[file] = lines
+	}
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	return strings.TrimSuffix(lines[line-1], "\r")
+}
@@ -0,0 +1,120 @@
+package report
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/thierry-f-78/go-syntax/pkg/types"
+)
+
+// Template is the format of a text/template executed for each issue, its
+// data the types.Issue:
+//
+//	{{.File}}:{{.Line}}: {{.Message}} ({{ruleURL .Rule}})
+//
+// The templates named header and footer, when defined, are executed
+// before and after the issues, their data the Report:
+//
+//	{{define "footer"}}{{len .Issues}} issues{{"\n"}}{{end}}
+//
+// The templates call the functions:
+//
+//	relPath path        the path relative to the current directory
+//	ruleURL rule        the documentation of the rule, if any
+//	sourceLine file n   the line n of file
+type Template struct {
+	tmpl *template.Template
+}
+
+// NewTemplate returns the format of the template text. The output of
+// each issue ends with a newline unless text does.
+func NewTemplate(text string) (*Template, error) {
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	var tmpl *template.Template
+	var err error
+	tmpl, err = template.New("issue").Funcs(templateFuncs(nil, nil)).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{tmpl: tmpl}, nil
+}
+
+// templateFuncs returns the functions of the templates, the URLs of the
+// rules found in r and the lines read in src.
+func templateFuncs(r *Report, src *sources) template.FuncMap {
+	return template.FuncMap{
+		"relPath": relPath,
+		"ruleURL": func(rule string) string {
+			if r == nil {
+				return ""
+			}
+			var i int
+			for i = range r.Rules {
+				if r.Rules[i].Name == rule {
+					return r.Rules[i].URL
+				}
+			}
+			return ""
+		},
+		"sourceLine": func(file string, line int) string {
+			if src == nil {
+				return ""
+			}
+			return src.line(file, line)
+		},
+	}
+}
+
+func (f *Template) Format(w io.Writer, r *Report) error {
+	// The functions see the report: each run gets a copy of its own.
+	var tmpl *template.Template
+	var err error
+	tmpl, err = f.tmpl.Clone()
+	if err != nil {
+		return err
+	}
+	tmpl.Funcs(templateFuncs(r, newSources(r)))
+
+	if tmpl.Lookup("header") != nil {
+		err = tmpl.ExecuteTemplate(w, "header", r)
+		if err != nil {
+			return err
+		}
+	}
+	var issue types.Issue
+	for _, issue = range r.Issues {
+		err = tmpl.Execute(w, issue)
+		if err != nil {
+			return err
+		}
+	}
+	if tmpl.Lookup("footer") != nil {
+		err = tmpl.ExecuteTemplate(w, "footer", r)
+	}
+	return err
+}
+
+// relPath returns path relative to the current directory when it is in
+// it, path otherwise.
+func relPath(path string) string {
+	if !filepath.IsAbs(path) {
+		return path
+	}
+	var wd string
+	var err error
+	wd, err = os.Getwd()
+	if err != nil {
+		return path
+	}
+	var rel string
+	rel, err = filepath.Rel(wd, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return rel
+}
@@ -0,0 +1,136 @@
+package report
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/thierry-f-78/go-syntax/pkg/rules"
+	"github.com/thierry-f-78/go-syntax/pkg/types"
+)
+
+func TestTemplate(t *testing.T) {
+	var dir string
+	dir = t.TempDir()
+	var err error
+	err = os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a\n\nfunc f() {\n\tx := 1\n}\n"), 0o644)
+	if err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	var r *Report
+	r = &Report{
+		Version: "v1.2.3",
+		Rules:   []rules.Info{{Name: "short-var-decl", URL: "https://example.com/rules#short-var-decl"}},
+		Issues: []types.Issue{
+			{File: filepath.Join(dir, "a.go"), Line: 4, Column: 2, Message: "Short variable declaration", Rule: "short-var-decl"},
+			{File: "b.go", Line: 7, Column: 1, Message: "Named returns", Rule: "named-returns"},
+		},
+	}
+
+	var tests []struct {
+		name     string
+		format   string
+		options  Options
+		expected string
+	}
+	tests = []struct {
+		name     string
+		format   string
+		options  Options
+		expected string
+	}{
+		{
+			name:     "issue fields",
+			format:   "template={{.Rule}} {{.Line}}:{{.Column}} {{.Message}}",
+			expected: "short-var-decl 4:2 Short variable declaration\nnamed-returns 7:1 Named returns\n",
+		},
+		{
+			name:     "helpers",
+			format:   "template={{ruleURL .Rule}}|{{sourceLine .File .Line}}|{{relPath .File}}",
+			expected: "https://example.com/rules#short-var-decl|\tx := 1|" + filepath.Join(dir, "a.go") + "\n||b.go\n",
+		},
+		{
+			name:   "header and footer",
+			format: "template",
+			options: Options{Template: "{{define \"header\"}}go-syntax {{.Version}}\n{{end}}" +
+				"{{define \"footer\"}}{{len .Issues}} issues\n{{end}}" +
+				"- {{.Rule}}\n"},
+			expected: "go-syntax v1.2.3\n- short-var-decl\n- named-returns\n2 issues\n",
+		},
+	}
+
+	var tt struct {
+		name     string
+		format   string
+		options  Options
+		expected string
+	}
+	for _, tt = range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var f Formatter
+			var err error
+			f, err = New(tt.format, tt.options)
+			if err != nil {
+				t.Fatalf("Failed to create formatter: %v", err)
+			}
+			var out bytes.Buffer
+			err = f.Format(&out, r)
+			if err != nil {
+				t.Fatalf("Failed to format: %v", err)
+			}
+			if out.String() != tt.expected {
+				t.Errorf("Expected:\n%q\ngot:\n%q", tt.expected, out.String())
+			}
+		})
+	}
+}
+
+func TestTemplateReadFile(t *testing.T) {
+	var r *Report
+	r = &Report{
+		Issues: []types.Issue{{File: "a.go", Line: 3, Column: 5, Message: "Variable declaration without type", Rule: "var-no-type"}},
+		ReadFile: func(name string) ([]byte, error) {
+			return []byte("package a\n\nvar y = 2\n"), nil
+		},
+	}
+
+	var f Formatter
+	var err error
+	f, err = New("template={{sourceLine .File .Line}}", Options{})
+	if err != nil {
+		t.Fatalf("Failed to create formatter: %v", err)
+	}
+	var out bytes.Buffer
+	err = f.Format(&out, r)
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+	if out.String() != "var y = 2\n" {
+		t.Errorf("Expected the line read by ReadFile, got %q", out.String())
+	}
+}
+
+func TestTemplateErrors(t *testing.T) {
+	var err error
+	_, err = New("template", Options{})
+	if err == nil {
+		t.Errorf("Expected an error without template")
+	}
+	_, err = New("template={{.File", Options{})
+	if err == nil {
+		t.Errorf("Expected a parse error")
+	}
+
+	var f Formatter
+	f, err = New("template={{.Unknown}}", Options{})
+	if err != nil {
+		t.Fatalf("Failed to create formatter: %v", err)
+	}
+	var out bytes.Buffer
+	err = f.Format(&out, &Report{Issues: []types.Issue{{File: "a.go"}}})
+	if err == nil {
+		t.Errorf("Expected an execution error, got %q", out.String())
+	}
+}
@@ -0,0 +1,48 @@
+package report
+
+import (
+	"fmt"
+	"io"
+)
+
+// Text is the format read by people, an issue per line:
+//
+//	file:line:column: [rule] message
+type Text struct {
+	Color   bool
+	Verbose bool
+}
+
+func (f *Text) Format(w io.Writer, r *Report) error {
+	var red string
+	var blue string
+	var reset string
+	if f.Color {
+		red = "\033[31m"
+		blue = "\033[34m"
+		reset = "\033[0m"
+	}
+
+	var err error
+	var i int
+	for i = range r.Issues {
+		_, err = fmt.Fprintf(w, "%s%s:%d:%d: [%s] %s%s\n",
+			red, r.Issues[i].File, r.Issues[i].Line, r.Issues[i].Column,
+			r.Issues[i].Rule, r.Issues[i].Message, reset,
+		)
+		if err != nil {
+			return err
+		}
+		if f.Verbose {
+			_, err = fmt.Fprintf(w, "  %s%s%s\n\n", blue, r.Issues[i].Description, reset)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if f.Verbose {
+		_, err = fmt.Fprintf(w, "Analyzed %d files\n", len(r.Files))
+	}
+	return err
+}
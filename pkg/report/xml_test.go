@@ -0,0 +1,97 @@
+package report
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/thierry-f-78/go-syntax/pkg/types"
+)
+
+func TestCheckstyle(t *testing.T) {
+	var r *Report
+	r = testReport()
+	r.Issues = append(r.Issues, types.Issue{File: "a.go", Line: 9, Column: 3, Message: "<if> & init", Rule: "if-init"})
+
+	var out bytes.Buffer
+	var err error
+	err = (&Checkstyle{}).Format(&out, r)
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+	var expected string
+	expected = `<?xml version="1.0" encoding="UTF-8"?>
+<checkstyle version="5.0">
+  <file name="a.go">
+    <error line="4" column="2" severity="error" message="Short variable declaration &#39;:=&#39; is not allowed" source="short-var-decl"></error>
+    <error line="9" column="3" severity="error" message="&lt;if&gt; &amp; init" source="if-init"></error>
+  </file>
+  <file name="b.go">
+    <error line="7" column="1" severity="error" message="Named return values are not allowed" source="named-returns"></error>
+  </file>
+</checkstyle>
+`
+	if out.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestJUnit(t *testing.T) {
+	var r *Report
+	r = &Report{
+		Files: []string{"a.go", "pkg/b.go", "pkg/c.go"},
+		Issues: []types.Issue{
+			{File: "pkg/b.go", Line: 4, Column: 2, Message: "First", Description: "Why", Rule: "short-var-decl"},
+			{File: "pkg/b.go", Line: 5, Column: 2, Message: "Second", Rule: "short-var-decl"},
+			{File: ".go-syntax.yml", Line: 1, Column: 1, Message: "Invalid", Rule: "config"},
+		},
+	}
+
+	var out bytes.Buffer
+	var err error
+	err = (&JUnit{}).Format(&out, r)
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+
+	var decoded junitTestSuites
+	err = xml.Unmarshal(out.Bytes(), &decoded)
+	if err != nil {
+		t.Fatalf("Invalid XML: %v\n%s", err, out.String())
+	}
+	if decoded.Tests != 4 || decoded.Failures != 2 || len(decoded.Suites) != 2 {
+		t.Fatalf("Expected 4 tests, 2 failures and 2 suites, got:\n%s", out.String())
+	}
+
+	var tests []struct {
+		suite    string
+		tests    int
+		failures int
+	}
+	tests = []struct {
+		suite    string
+		tests    int
+		failures int
+	}{
+		{suite: ".", tests: 2, failures: 1},
+		{suite: "pkg", tests: 2, failures: 1},
+	}
+	var i int
+	for i = range tests {
+		var suite junitTestSuite
+		suite = decoded.Suites[i]
+		if suite.Name != tests[i].suite || suite.Tests != tests[i].tests || suite.Failures != tests[i].failures {
+			t.Errorf("Expected suite %s with %d tests and %d failures, got %s with %d and %d",
+				tests[i].suite, tests[i].tests, tests[i].failures, suite.Name, suite.Tests, suite.Failures)
+		}
+	}
+
+	var failures []junitFailure
+	failures = decoded.Suites[1].Cases[0].Failures
+	if len(failures) != 2 || failures[0].Type != "short-var-decl" || failures[0].Text != "pkg/b.go:4:2: [short-var-decl] First\nWhy" {
+		t.Errorf("Expected the 2 failures of pkg/b.go, got %+v", failures)
+	}
+	if len(decoded.Suites[1].Cases[1].Failures) != 0 {
+		t.Errorf("Expected pkg/c.go to pass, got %+v", decoded.Suites[1].Cases[1].Failures)
+	}
+}
@@ -0,0 +1,34 @@
+package rules
+
+import (
+	"fmt"
+)
+
+// setBool sets the boolean option name from its configured value.
+func setBool(dst *bool, name string, value interface{}) error {
+	var b bool
+	var ok bool
+	b, ok = value.(bool)
+	if !ok {
+		return fmt.Errorf("option %q expects a boolean, got %v", name, value)
+	}
+	*dst = b
+	return nil
+}
+
+// setInt sets the non-negative integer option name from its configured
+// value.
+func setInt(dst *int, name string, value interface{}) error {
+	var n int
+	var ok bool
+	n, ok = value.(int)
+	if !ok || n < 0 {
+		return fmt.Errorf("option %q expects a non-negative integer, got %v", name, value)
+	}
+	*dst = n
+	return nil
+}
+
+func unknownOption(name string) error {
+	return fmt.Errorf("unknown option %q", name)
+}
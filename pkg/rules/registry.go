@@ -0,0 +1,145 @@
+package rules
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/thierry-f-78/go-syntax/pkg/types"
+)
+
+// Info describes a rule of the registry.
+type Info struct {
+	Name        string
+	Description string
+	// Category groups related rules in listings.
+	Category string
+	// URL is the documentation of the rule, if any.
+	URL string
+	// Enabled tells whether the rule runs when the configuration does not
+	// mention it.
+	Enabled bool
+	Options []OptionInfo
+	// New returns an instance of the rule, its options at their default.
+	New func() types.Rule
+}
+
+// OptionInfo describes an option of a rule.
+type OptionInfo struct {
+	Name string
+	// Type is the type of the value: "bool", "int", "string" or "list".
+	Type        string
+	Default     interface{}
+	Description string
+}
+
+var (
+	registryMu sync.Mutex
+	registry   []Info
+)
+
+// Register adds a rule to the registry, making it available to the
+// linters and to the command line. It is meant to be called from the init
+// function of the package defining the rule, and panics when the name is
+// empty or already registered, or when New is nil.
+func Register(info Info) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if info.Name == "" || info.New == nil {
+		panic("rules: Register needs a rule name and a New function")
+	}
+	var registered Info
+	for _, registered = range registry {
+		if registered.Name == info.Name {
+			panic(fmt.Sprintf("rules: Register called twice for rule %q", info.Name))
+		}
+	}
+	registry = append(registry, info)
+}
+
+// All returns the registered rules, in registration order.
+func All() []Info {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	var all []Info
+	all = append(all, registry...)
+	return all
+}
+
+// Lookup returns the registered rule name.
+func Lookup(name string) (Info, bool) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	var info Info
+	for _, info = range registry {
+		if info.Name == name {
+			return info, true
+		}
+	}
+	return Info{}, false
+}
+
+// docURL documents the built-in rules.
+const docURL string = "https://github.com/thierry-f-78/go-syntax#detection-rules"
+
+func init() {
+	Register(Info{
+		Name:        "short-var-decl",
+		Description: "Short variable declarations ':=' outside of type switches",
+		Category:    "declarations",
+		URL:         docURL,
+		Enabled:     true,
+		Options: []OptionInfo{
+			{Name: "allow-range", Type: "bool", Default: false, Description: "Accept 'for k, v := range'"},
+		},
+		New: func() types.Rule { return &ShortVarDeclRule{} },
+	})
+	Register(Info{
+		Name:        "var-no-type",
+		Description: "Variable declarations without explicit type",
+		Category:    "declarations",
+		URL:         docURL,
+		Enabled:     true,
+		New:         func() types.Rule { return &VarNoTypeRule{} },
+	})
+	Register(Info{
+		Name:        "const-no-type",
+		Description: "Constant declarations without explicit type",
+		Category:    "declarations",
+		URL:         docURL,
+		Enabled:     true,
+		New:         func() types.Rule { return &ConstNoTypeRule{} },
+	})
+	Register(Info{
+		Name:        "named-returns",
+		Description: "Functions with named return parameters",
+		Category:    "functions",
+		URL:         docURL,
+		Enabled:     true,
+		New:         func() types.Rule { return &NamedReturnsRule{} },
+	})
+	Register(Info{
+		Name:        "naked-return",
+		Description: "Naked returns in functions with named return parameters",
+		Category:    "functions",
+		URL:         docURL,
+		Enabled:     true,
+		Options: []OptionInfo{
+			{Name: "max-func-lines", Type: "int", Default: 0, Description: "Accept naked returns in functions of up to that many lines"},
+		},
+		New: func() types.Rule { return &NakedReturnRule{} },
+	})
+	Register(Info{
+		Name:        "if-init",
+		Description: "If statements with an initialization",
+		Category:    "control-flow",
+		URL:         docURL,
+		Enabled:     true,
+		Options: []OptionInfo{
+			{Name: "allow-err-check", Type: "bool", Default: false, Description: "Accept exactly 'if err := f(); err != nil'"},
+		},
+		New: func() types.Rule { return &IfInitRule{} },
+	})
+}
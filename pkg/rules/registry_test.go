@@ -0,0 +1,92 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/thierry-f-78/go-syntax/pkg/types"
+)
+
+func TestRegistry(t *testing.T) {
+	var all []Info
+	all = All()
+	if len(all) != 6 {
+		t.Fatalf("Expected 6 registered rules, got %d", len(all))
+	}
+
+	var info Info
+	for _, info = range all {
+		var rule types.Rule
+		rule = info.New()
+		if rule.Name() != info.Name {
+			t.Errorf("Rule registered as %q is named %q", info.Name, rule.Name())
+		}
+
+		var option OptionInfo
+		for _, option = range info.Options {
+			var configurable types.ConfigurableRule
+			var ok bool
+			configurable, ok = rule.(types.ConfigurableRule)
+			if !ok {
+				t.Errorf("Rule %q declares options but has no SetOption", info.Name)
+				continue
+			}
+			var err error
+			err = configurable.SetOption(option.Name, option.Default)
+			if err != nil {
+				t.Errorf("Rule %q rejects the default of %q: %v", info.Name, option.Name, err)
+			}
+		}
+
+		var found Info
+		var ok bool
+		found, ok = Lookup(info.Name)
+		if !ok || found.Name != info.Name {
+			t.Errorf("Expected Lookup to find %q", info.Name)
+		}
+	}
+
+	var ok bool
+	_, ok = Lookup("no-such-rule")
+	if ok {
+		t.Errorf("Expected Lookup to fail for an unknown rule")
+	}
+}
+
+func TestRegisterPanics(t *testing.T) {
+	var tests []struct {
+		name string
+		info Info
+	}
+	tests = []struct {
+		name string
+		info Info
+	}{
+		{
+			name: "duplicate name",
+			info: Info{Name: "if-init", New: func() types.Rule { return &IfInitRule{} }},
+		},
+		{
+			name: "no name",
+			info: Info{New: func() types.Rule { return &IfInitRule{} }},
+		},
+		{
+			name: "no constructor",
+			info: Info{Name: "no-constructor"},
+		},
+	}
+
+	var tt struct {
+		name string
+		info Info
+	}
+	for _, tt = range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected Register to panic")
+				}
+			}()
+			Register(tt.info)
+		})
+	}
+}
@@ -3,73 +3,78 @@ package rules
 import (
 	"go/ast"
 	"go/token"
+	gotypes "go/types"
 
 	"github.com/thierry-f-78/go-syntax/pkg/types"
 )
 
-type ShortVarDeclRule struct{}
+type ShortVarDeclRule struct {
+	// AllowRange accepts 'for k, v := range'.
+	AllowRange bool
+}
 
 func (r *ShortVarDeclRule) Name() string {
 	return "short-var-decl"
 }
 
-func (r *ShortVarDeclRule) Check(fset *token.FileSet, file *ast.File) []types.Issue {
-	var issues []types.Issue
-
-	ast.Inspect(file, func(n ast.Node) bool {
-		switch node := n.(type) {
-		case *ast.AssignStmt:
-			if node.Tok == token.DEFINE {
-				if !r.isInTypeSwitch(node, file) {
-					var pos token.Position
-					pos = fset.Position(node.Pos())
-					issues = append(issues, types.Issue{
-						File:        pos.Filename,
-						Line:        pos.Line,
-						Column:      pos.Column,
-						Message:     "Short variable declaration ':=' is not allowed",
-						Description: "Avoid ':=': unclear types make reviews harder, bugs likelier.",
-						Rule:        r.Name(),
-					})
-				}
-			}
-		case *ast.RangeStmt:
-			if node.Tok == token.DEFINE {
-				var pos token.Position
-				pos = fset.Position(node.Pos())
-				issues = append(issues, types.Issue{
-					File:        pos.Filename,
-					Line:        pos.Line,
-					Column:      pos.Column,
-					Message:     "Short variable declaration ':=' is not allowed in range",
-					Description: "Avoid ':=': unclear types make reviews harder, bugs likelier.",
-					Rule:        r.Name(),
-				})
-			}
-		}
-		return true
-	})
+func (r *ShortVarDeclRule) SetOption(name string, value interface{}) error {
+	switch name {
+	case "allow-range":
+		return setBool(&r.AllowRange, name, value)
+	}
+	return unknownOption(name)
+}
 
-	return issues
+func (r *ShortVarDeclRule) Check(fset *token.FileSet, file *ast.File) []types.Issue {
+	return walk(&types.Pass{Fset: fset, File: file}, r)
 }
 
-func (r *ShortVarDeclRule) isInTypeSwitch(assign *ast.AssignStmt, file *ast.File) bool {
-	var found bool
+func (r *ShortVarDeclRule) NodeTypes() []ast.Node {
+	return []ast.Node{(*ast.AssignStmt)(nil), (*ast.RangeStmt)(nil)}
+}
 
-	ast.Inspect(file, func(n ast.Node) bool {
-		var typeSwitch *ast.TypeSwitchStmt
-		var ok bool
-		typeSwitch, ok = n.(*ast.TypeSwitchStmt)
-		if ok {
-			if typeSwitch.Assign == assign {
-				found = true
-				return false
-			}
+func (r *ShortVarDeclRule) Visit(pass *types.Pass, n ast.Node, stack []ast.Node) []types.Issue {
+	switch node := n.(type) {
+	case *ast.AssignStmt:
+		if node.Tok == token.DEFINE && !isTypeSwitchAssign(node, stack) {
+			var pos token.Position
+			pos = pass.Fset.Position(node.Pos())
+			return []types.Issue{{
+				File:        pos.Filename,
+				Line:        pos.Line,
+				Column:      pos.Column,
+				Message:     "Short variable declaration ':=' is not allowed",
+				Description: "Avoid ':=': unclear types make reviews harder, bugs likelier.",
+				Rule:        r.Name(),
+			}}
 		}
-		return true
-	})
+	case *ast.RangeStmt:
+		if node.Tok == token.DEFINE && !r.AllowRange {
+			var pos token.Position
+			pos = pass.Fset.Position(node.Pos())
+			return []types.Issue{{
+				File:        pos.Filename,
+				Line:        pos.Line,
+				Column:      pos.Column,
+				Message:     "Short variable declaration ':=' is not allowed in range",
+				Description: "Avoid ':=': unclear types make reviews harder, bugs likelier.",
+				Rule:        r.Name(),
+			}}
+		}
+	}
+	return nil
+}
 
-	return found
+// isTypeSwitchAssign tells whether assign is the 'v := x.(type)' of a
+// type switch, its parent being the last node of stack.
+func isTypeSwitchAssign(assign *ast.AssignStmt, stack []ast.Node) bool {
+	if len(stack) == 0 {
+		return false
+	}
+	var typeSwitch *ast.TypeSwitchStmt
+	var ok bool
+	typeSwitch, ok = stack[len(stack)-1].(*ast.TypeSwitchStmt)
+	return ok && typeSwitch.Assign == assign
 }
 
 // hasExplicitType checks if an expression contains an explicit type
@@ -141,7 +146,8 @@ func isTypeExpression(expr ast.Expr) bool {
 		return true
 	case *ast.Ident:
 		// Basic types: int, string, bool, etc.
-		// Note: This might include variable names too, but in the context of
+		// Note: This might include variable names too; without type
+		// information, in the context of
 		// "var x = TYPE", if it's a valid Go program, TYPE should be a type
 		return true
 	}
@@ -155,42 +161,63 @@ func (r *VarNoTypeRule) Name() string {
 }
 
 func (r *VarNoTypeRule) Check(fset *token.FileSet, file *ast.File) []types.Issue {
-	var issues []types.Issue
+	return walk(&types.Pass{Fset: fset, File: file}, r)
+}
+
+// CheckTyped tells the types from the variables: an identifier or a
+// selector value is accepted only when it denotes a type, so that
+// 'var x = y' is reported.
+func (r *VarNoTypeRule) CheckTyped(fset *token.FileSet, file *ast.File, pkg *gotypes.Package, info *gotypes.Info) []types.Issue {
+	return walk(&types.Pass{Fset: fset, File: file, Pkg: pkg, Info: info}, r)
+}
 
-	ast.Inspect(file, func(n ast.Node) bool {
-		switch node := n.(type) {
-		case *ast.GenDecl:
-			if node.Tok == token.VAR {
-				var spec ast.Spec
-				for _, spec = range node.Specs {
-					var valueSpec *ast.ValueSpec
-					var ok bool
-					valueSpec, ok = spec.(*ast.ValueSpec)
-					if ok {
-						// Check if type is not specified but values are provided
-						// Exception: allow when the value has an explicit type, is an unambiguous literal, or is a type expression
-						if valueSpec.Type == nil && len(valueSpec.Values) > 0 && !hasExplicitType(valueSpec.Values[0]) && !isUnambiguousLiteral(valueSpec.Values[0]) && !isTypeExpression(valueSpec.Values[0]) {
-							var pos token.Position
-							pos = fset.Position(valueSpec.Pos())
-							issues = append(issues, types.Issue{
-								File:        pos.Filename,
-								Line:        pos.Line,
-								Column:      pos.Column,
-								Message:     "Variable declaration without explicit type is not allowed",
-								Description: "Avoid 'var x = value': unclear types make reviews harder, bugs likelier.",
-								Rule:        r.Name(),
-							})
-						}
-					}
-				}
+func (r *VarNoTypeRule) NodeTypes() []ast.Node {
+	return []ast.Node{(*ast.GenDecl)(nil)}
+}
+
+func (r *VarNoTypeRule) Visit(pass *types.Pass, n ast.Node, stack []ast.Node) []types.Issue {
+	var node *ast.GenDecl = n.(*ast.GenDecl)
+	if node.Tok != token.VAR {
+		return nil
+	}
+
+	var issues []types.Issue
+	var spec ast.Spec
+	for _, spec = range node.Specs {
+		var valueSpec *ast.ValueSpec
+		var ok bool
+		valueSpec, ok = spec.(*ast.ValueSpec)
+		if ok {
+			// Check if type is not specified but values are provided
+			// Exception: allow when the value has an explicit type, is an unambiguous literal, or is a type expression
+			if valueSpec.Type == nil && len(valueSpec.Values) > 0 && !hasExplicitType(valueSpec.Values[0]) && !isUnambiguousLiteral(valueSpec.Values[0]) && !isType(pass.Info, valueSpec.Values[0]) {
+				var pos token.Position
+				pos = pass.Fset.Position(valueSpec.Pos())
+				issues = append(issues, types.Issue{
+					File:        pos.Filename,
+					Line:        pos.Line,
+					Column:      pos.Column,
+					Message:     "Variable declaration without explicit type is not allowed",
+					Description: "Avoid 'var x = value': unclear types make reviews harder, bugs likelier.",
+					Rule:        r.Name(),
+				})
 			}
 		}
-		return true
-	})
-
+	}
 	return issues
 }
 
+// isType checks if expr denotes a type, using info when available.
+func isType(info *gotypes.Info, expr ast.Expr) bool {
+	if info != nil {
+		switch expr.(type) {
+		case *ast.Ident, *ast.SelectorExpr:
+			return info.Types[expr].IsType()
+		}
+	}
+	return isTypeExpression(expr)
+}
+
 type NamedReturnsRule struct{}
 
 func (r *NamedReturnsRule) Name() string {
@@ -198,96 +225,107 @@ func (r *NamedReturnsRule) Name() string {
 }
 
 func (r *NamedReturnsRule) Check(fset *token.FileSet, file *ast.File) []types.Issue {
-	var issues []types.Issue
+	return walk(&types.Pass{Fset: fset, File: file}, r)
+}
 
-	ast.Inspect(file, func(n ast.Node) bool {
-		switch node := n.(type) {
-		case *ast.FuncDecl:
-			if node.Type.Results != nil && len(node.Type.Results.List) > 0 {
-				var field *ast.Field
-				for _, field = range node.Type.Results.List {
-					// Check if any return parameter has a name
-					if len(field.Names) > 0 {
-						var pos token.Position
-						pos = fset.Position(field.Pos())
-						issues = append(issues, types.Issue{
-							File:        pos.Filename,
-							Line:        pos.Line,
-							Column:      pos.Column,
-							Message:     "Named return parameters are not allowed",
-							Description: "Avoid named returns: unclear what is returned, harder to review.",
-							Rule:        r.Name(),
-						})
-					}
-				}
-			}
-		}
-		return true
-	})
+func (r *NamedReturnsRule) NodeTypes() []ast.Node {
+	return []ast.Node{(*ast.FuncDecl)(nil)}
+}
 
+func (r *NamedReturnsRule) Visit(pass *types.Pass, n ast.Node, stack []ast.Node) []types.Issue {
+	var node *ast.FuncDecl = n.(*ast.FuncDecl)
+	if node.Type.Results == nil {
+		return nil
+	}
+
+	var issues []types.Issue
+	var field *ast.Field
+	for _, field = range node.Type.Results.List {
+		// Check if any return parameter has a name
+		if len(field.Names) > 0 {
+			var pos token.Position
+			pos = pass.Fset.Position(field.Pos())
+			issues = append(issues, types.Issue{
+				File:        pos.Filename,
+				Line:        pos.Line,
+				Column:      pos.Column,
+				Message:     "Named return parameters are not allowed",
+				Description: "Avoid named returns: unclear what is returned, harder to review.",
+				Rule:        r.Name(),
+			})
+		}
+	}
 	return issues
 }
 
-type NakedReturnRule struct{}
+type NakedReturnRule struct {
+	// MaxFuncLines accepts naked returns in functions of up to this many
+	// lines. Zero forbids them everywhere.
+	MaxFuncLines int
+}
 
 func (r *NakedReturnRule) Name() string {
 	return "naked-return"
 }
 
+func (r *NakedReturnRule) SetOption(name string, value interface{}) error {
+	switch name {
+	case "max-func-lines":
+		return setInt(&r.MaxFuncLines, name, value)
+	}
+	return unknownOption(name)
+}
+
 func (r *NakedReturnRule) Check(fset *token.FileSet, file *ast.File) []types.Issue {
-	var issues []types.Issue
+	return walk(&types.Pass{Fset: fset, File: file}, r)
+}
 
-	ast.Inspect(file, func(n ast.Node) bool {
-		switch node := n.(type) {
-		case *ast.ReturnStmt:
-			// Check if it's a naked return (no explicit values)
-			if len(node.Results) == 0 {
-				// Find the containing function to check if it has named returns
-				var containingFunc *ast.FuncDecl
-				ast.Inspect(file, func(fn ast.Node) bool {
-					var funcDecl *ast.FuncDecl
-					var ok bool
-					funcDecl, ok = fn.(*ast.FuncDecl)
-					if ok {
-						// Check if the return statement is within this function
-						if funcDecl.Pos() <= node.Pos() && node.Pos() <= funcDecl.End() {
-							containingFunc = funcDecl
-							return false
-						}
-					}
-					return true
-				})
+func (r *NakedReturnRule) NodeTypes() []ast.Node {
+	return []ast.Node{(*ast.ReturnStmt)(nil)}
+}
 
-				// If we found a containing function and it has named returns, flag it
-				if containingFunc != nil && containingFunc.Type.Results != nil {
-					var hasNamedReturns bool = false
-					var field *ast.Field
-					for _, field = range containingFunc.Type.Results.List {
-						if len(field.Names) > 0 {
-							hasNamedReturns = true
-							break
-						}
-					}
-
-					if hasNamedReturns {
-						var pos token.Position
-						pos = fset.Position(node.Pos())
-						issues = append(issues, types.Issue{
-							File:        pos.Filename,
-							Line:        pos.Line,
-							Column:      pos.Column,
-							Message:     "Naked return is not allowed",
-							Description: "Avoid naked returns: unclear what values are returned.",
-							Rule:        r.Name(),
-						})
-					}
-				}
-			}
+func (r *NakedReturnRule) Visit(pass *types.Pass, n ast.Node, stack []ast.Node) []types.Issue {
+	var node *ast.ReturnStmt = n.(*ast.ReturnStmt)
+	// Check if it's a naked return (no explicit values)
+	if len(node.Results) > 0 {
+		return nil
+	}
+
+	// Find the containing function among the ancestors
+	var containingFunc *ast.FuncDecl
+	var i int
+	for i = len(stack) - 1; i >= 0 && containingFunc == nil; i-- {
+		containingFunc, _ = stack[i].(*ast.FuncDecl)
+	}
+
+	// If we found a containing function and it has named returns, flag it
+	if containingFunc == nil || containingFunc.Type.Results == nil {
+		return nil
+	}
+	var hasNamedReturns bool = false
+	var field *ast.Field
+	for _, field = range containingFunc.Type.Results.List {
+		if len(field.Names) > 0 {
+			hasNamedReturns = true
+			break
 		}
-		return true
-	})
+	}
 
-	return issues
+	var lines int
+	lines = pass.Fset.Position(containingFunc.End()).Line - pass.Fset.Position(containingFunc.Pos()).Line + 1
+	if !hasNamedReturns || (r.MaxFuncLines != 0 && lines <= r.MaxFuncLines) {
+		return nil
+	}
+	var pos token.Position
+	pos = pass.Fset.Position(node.Pos())
+	return []types.Issue{{
+		File:        pos.Filename,
+		Line:        pos.Line,
+		Column:      pos.Column,
+		Message:     "Naked return is not allowed",
+		Description: "Avoid naked returns: unclear what values are returned.",
+		Rule:        r.Name(),
+	}}
 }
 
 type ConstNoTypeRule struct{}
@@ -297,69 +335,111 @@ func (r *ConstNoTypeRule) Name() string {
 }
 
 func (r *ConstNoTypeRule) Check(fset *token.FileSet, file *ast.File) []types.Issue {
-	var issues []types.Issue
-
-	ast.Inspect(file, func(n ast.Node) bool {
-		switch node := n.(type) {
-		case *ast.GenDecl:
-			if node.Tok == token.CONST {
-				var spec ast.Spec
-				for _, spec = range node.Specs {
-					var valueSpec *ast.ValueSpec
-					var ok bool
-					valueSpec, ok = spec.(*ast.ValueSpec)
-					if ok {
-						// Check if type is not specified but values are provided
-						// Exception: allow when the value is an unambiguous literal
-						if valueSpec.Type == nil && len(valueSpec.Values) > 0 && !isUnambiguousLiteral(valueSpec.Values[0]) {
-							var pos token.Position
-							pos = fset.Position(valueSpec.Pos())
-							issues = append(issues, types.Issue{
-								File:        pos.Filename,
-								Line:        pos.Line,
-								Column:      pos.Column,
-								Message:     "Constant declaration without explicit type is not allowed",
-								Description: "Avoid 'const x = value': unclear types make reviews harder, bugs likelier.",
-								Rule:        r.Name(),
-							})
-						}
-					}
-				}
-			}
-		}
-		return true
-	})
-
-	return issues
+	return walk(&types.Pass{Fset: fset, File: file}, r)
 }
 
-type IfInitRule struct{}
-
-func (r *IfInitRule) Name() string {
-	return "if-init"
+func (r *ConstNoTypeRule) NodeTypes() []ast.Node {
+	return []ast.Node{(*ast.GenDecl)(nil)}
 }
 
-func (r *IfInitRule) Check(fset *token.FileSet, file *ast.File) []types.Issue {
-	var issues []types.Issue
+func (r *ConstNoTypeRule) Visit(pass *types.Pass, n ast.Node, stack []ast.Node) []types.Issue {
+	var node *ast.GenDecl = n.(*ast.GenDecl)
+	if node.Tok != token.CONST {
+		return nil
+	}
 
-	ast.Inspect(file, func(n ast.Node) bool {
-		switch node := n.(type) {
-		case *ast.IfStmt:
-			if node.Init != nil {
+	var issues []types.Issue
+	var spec ast.Spec
+	for _, spec = range node.Specs {
+		var valueSpec *ast.ValueSpec
+		var ok bool
+		valueSpec, ok = spec.(*ast.ValueSpec)
+		if ok {
+			// Check if type is not specified but values are provided
+			// Exception: allow when the value is an unambiguous literal
+			if valueSpec.Type == nil && len(valueSpec.Values) > 0 && !isUnambiguousLiteral(valueSpec.Values[0]) {
 				var pos token.Position
-				pos = fset.Position(node.Pos())
+				pos = pass.Fset.Position(valueSpec.Pos())
 				issues = append(issues, types.Issue{
 					File:        pos.Filename,
 					Line:        pos.Line,
 					Column:      pos.Column,
-					Message:     "If statement with initialization is not allowed.",
-					Description: "Avoid 'if stmt; cond': uncommon, unreadable, breaks flow.",
+					Message:     "Constant declaration without explicit type is not allowed",
+					Description: "Avoid 'const x = value': unclear types make reviews harder, bugs likelier.",
 					Rule:        r.Name(),
 				})
 			}
 		}
-		return true
-	})
-
+	}
 	return issues
 }
+
+type IfInitRule struct {
+	// AllowErrCheck accepts 'if err := f(); err != nil'.
+	AllowErrCheck bool
+}
+
+func (r *IfInitRule) Name() string {
+	return "if-init"
+}
+
+func (r *IfInitRule) SetOption(name string, value interface{}) error {
+	switch name {
+	case "allow-err-check":
+		return setBool(&r.AllowErrCheck, name, value)
+	}
+	return unknownOption(name)
+}
+
+func (r *IfInitRule) Check(fset *token.FileSet, file *ast.File) []types.Issue {
+	return walk(&types.Pass{Fset: fset, File: file}, r)
+}
+
+func (r *IfInitRule) NodeTypes() []ast.Node {
+	return []ast.Node{(*ast.IfStmt)(nil)}
+}
+
+func (r *IfInitRule) Visit(pass *types.Pass, n ast.Node, stack []ast.Node) []types.Issue {
+	var node *ast.IfStmt = n.(*ast.IfStmt)
+	if node.Init == nil || (r.AllowErrCheck && isErrCheck(node)) {
+		return nil
+	}
+	var pos token.Position
+	pos = pass.Fset.Position(node.Pos())
+	return []types.Issue{{
+		File:        pos.Filename,
+		Line:        pos.Line,
+		Column:      pos.Column,
+		Message:     "If statement with initialization is not allowed.",
+		Description: "Avoid 'if stmt; cond': uncommon, unreadable, breaks flow.",
+		Rule:        r.Name(),
+	}}
+}
+
+// isErrCheck checks if an if statement is exactly 'if err := call; err != nil'
+func isErrCheck(ifStmt *ast.IfStmt) bool {
+	var assign *ast.AssignStmt
+	var ok bool
+	assign, ok = ifStmt.Init.(*ast.AssignStmt)
+	if !ok || assign.Tok != token.DEFINE || len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+		return false
+	}
+	if !isIdent(assign.Lhs[0], "err") {
+		return false
+	}
+	_, ok = assign.Rhs[0].(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+
+	var cond *ast.BinaryExpr
+	cond, ok = ifStmt.Cond.(*ast.BinaryExpr)
+	return ok && cond.Op == token.NEQ && isIdent(cond.X, "err") && isIdent(cond.Y, "nil")
+}
+
+func isIdent(expr ast.Expr, name string) bool {
+	var ident *ast.Ident
+	var ok bool
+	ident, ok = expr.(*ast.Ident)
+	return ok && ident.Name == name
+}
@@ -2,8 +2,10 @@ package rules
 
 import (
 	"go/ast"
+	"go/importer"
 	"go/parser"
 	"go/token"
+	gotypes "go/types"
 	"testing"
 
 	"github.com/thierry-f-78/go-syntax/pkg/types"
@@ -323,6 +325,82 @@ func main() {
 	}
 }
 
+func TestVarNoTypeRuleTyped(t *testing.T) {
+	var tests []struct {
+		name     string
+		code     string
+		expected int
+	}
+	tests = []struct {
+		name     string
+		code     string
+		expected int
+	}{
+		{
+			name: "var from a variable - should detect",
+			code: `package main
+var y int = 1
+var x = y
+`,
+			expected: 1,
+		},
+		{
+			name: "var from a package variable - should detect",
+			code: `package main
+import "math"
+var pi = math.Pi
+`,
+			expected: 1,
+		},
+		{
+			name: "var with explicit type - should not detect",
+			code: `package main
+var y int = 1
+var x int = y
+var s = "s"
+`,
+			expected: 0,
+		},
+	}
+
+	var rule *VarNoTypeRule
+	rule = &VarNoTypeRule{}
+
+	var tt struct {
+		name     string
+		code     string
+		expected int
+	}
+	for _, tt = range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fset *token.FileSet
+			fset = token.NewFileSet()
+			var file *ast.File
+			var err error
+			file, err = parser.ParseFile(fset, "test.go", tt.code, parser.ParseComments)
+			if err != nil {
+				t.Fatalf("Failed to parse code: %v", err)
+			}
+
+			var info *gotypes.Info
+			info = &gotypes.Info{Types: make(map[ast.Expr]gotypes.TypeAndValue)}
+			var conf gotypes.Config
+			conf = gotypes.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+			var pkg *gotypes.Package
+			pkg, err = conf.Check("main", fset, []*ast.File{file}, info)
+			if err != nil {
+				t.Fatalf("Failed to type-check code: %v", err)
+			}
+
+			var issues []types.Issue
+			issues = rule.CheckTyped(fset, file, pkg, info)
+			if len(issues) != tt.expected {
+				t.Errorf("Expected %d issues, got %d", tt.expected, len(issues))
+			}
+		})
+	}
+}
+
 func TestNamedReturnsRule(t *testing.T) {
 	var tests []struct {
 		name     string
@@ -863,3 +941,120 @@ func someFunc() error { return nil }`,
 		})
 	}
 }
+
+func TestRuleOptions(t *testing.T) {
+	var tests []struct {
+		name     string
+		rule     types.ConfigurableRule
+		option   string
+		value    interface{}
+		code     string
+		expected int
+	}
+	tests = []struct {
+		name     string
+		rule     types.ConfigurableRule
+		option   string
+		value    interface{}
+		code     string
+		expected int
+	}{
+		{
+			name:   "allow-range accepts range declarations",
+			rule:   &ShortVarDeclRule{},
+			option: "allow-range",
+			value:  true,
+			code: `package main
+func main() {
+	for i, v := range []int{1} {
+		x := i + v
+	}
+}`,
+			expected: 1,
+		},
+		{
+			name:   "allow-err-check accepts error checks",
+			rule:   &IfInitRule{},
+			option: "allow-err-check",
+			value:  true,
+			code: `package main
+func main() {
+	if err := someFunc(); err != nil {
+		return
+	}
+	if err := someFunc(); err == nil {
+		return
+	}
+	if v, err := other(); err != nil {
+		return
+	}
+}
+func someFunc() error { return nil }
+func other() (int, error) { return 0, nil }`,
+			expected: 2,
+		},
+		{
+			name:   "max-func-lines accepts naked returns in short functions",
+			rule:   &NakedReturnRule{},
+			option: "max-func-lines",
+			value:  3,
+			code: `package main
+func short() (err error) {
+	return
+}
+func long() (err error) {
+	err = nil
+	return
+}`,
+			expected: 1,
+		},
+	}
+
+	var tt struct {
+		name     string
+		rule     types.ConfigurableRule
+		option   string
+		value    interface{}
+		code     string
+		expected int
+	}
+	for _, tt = range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			err = tt.rule.SetOption(tt.option, tt.value)
+			if err != nil {
+				t.Fatalf("Failed to set option: %v", err)
+			}
+
+			var fset *token.FileSet
+			fset = token.NewFileSet()
+			var file *ast.File
+			file, err = parser.ParseFile(fset, "test.go", tt.code, parser.ParseComments)
+			if err != nil {
+				t.Fatalf("Failed to parse code: %v", err)
+			}
+
+			var issues []types.Issue
+			issues = tt.rule.Check(fset, file)
+			if len(issues) != tt.expected {
+				t.Errorf("Expected %d issues, got %d", tt.expected, len(issues))
+			}
+		})
+	}
+}
+
+func TestRuleOptionErrors(t *testing.T) {
+	var err error
+	err = (&ShortVarDeclRule{}).SetOption("allow-range", "yes")
+	if err == nil {
+		t.Errorf("Expected an error for a non-boolean value")
+	}
+	err = (&NakedReturnRule{}).SetOption("max-func-lines", -1)
+	if err == nil {
+		t.Errorf("Expected an error for a negative value")
+	}
+	err = (&IfInitRule{}).SetOption("unknown", true)
+	if err == nil {
+		t.Errorf("Expected an error for an unknown option")
+	}
+}
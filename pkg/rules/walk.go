@@ -0,0 +1,34 @@
+package rules
+
+import (
+	"go/ast"
+	"reflect"
+
+	"github.com/thierry-f-78/go-syntax/pkg/types"
+)
+
+// walk checks the nodes of pass.File with rule, walking the file once.
+// The linter walks the files once for all their node rules instead.
+func walk(pass *types.Pass, rule types.NodeRule) []types.Issue {
+	var visited map[reflect.Type]bool
+	visited = make(map[reflect.Type]bool)
+	var node ast.Node
+	for _, node = range rule.NodeTypes() {
+		visited[reflect.TypeOf(node)] = true
+	}
+
+	var issues []types.Issue
+	var stack []ast.Node
+	ast.Inspect(pass.File, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		if visited[reflect.TypeOf(n)] {
+			issues = append(issues, rule.Visit(pass, n, stack)...)
+		}
+		stack = append(stack, n)
+		return true
+	})
+	return issues
+}
@@ -3,6 +3,7 @@ package types
 import (
 	"go/ast"
 	"go/token"
+	gotypes "go/types"
 )
 
 type Issue struct {
@@ -12,9 +13,82 @@ type Issue struct {
 	Message     string
 	Description string
 	Rule        string
+
+	// Fix is the suggested fix of the issue, if any.
+	Fix *SuggestedFix
+	// NoFixReason explains why a fix was attempted but not suggested.
+	NoFixReason string
+	// Suppressed tells a //nolint comment silences the issue. Such
+	// issues are reported only when asked for.
+	Suppressed bool
+}
+
+// SuggestedFix is a change of the source fixing an issue. Its edits are
+// meant to be applied together, or not at all.
+type SuggestedFix struct {
+	Description string
+	Edits       []TextEdit
 }
 
+// TextEdit replaces the bytes [Start, End) of the issue's file with
+// NewText.
+type TextEdit struct {
+	Start   int
+	End     int
+	NewText string
+}
+
+// Rule checks the files one at a time. The linter checks several files
+// at once with the same rule: its methods, and those of the other rule
+// interfaces, must be safe for concurrent use.
 type Rule interface {
 	Name() string
 	Check(fset *token.FileSet, file *ast.File) []Issue
 }
+
+// ConfigurableRule is a rule taking options. They are set from the
+// configuration before any check.
+type ConfigurableRule interface {
+	Rule
+	SetOption(name string, value interface{}) error
+}
+
+// TypedRule is a rule using the type information of the package of the
+// file. The linter calls CheckTyped when the package type-checks, and
+// falls back to Check when it does not.
+type TypedRule interface {
+	Rule
+	CheckTyped(fset *token.FileSet, file *ast.File, pkg *gotypes.Package, info *gotypes.Info) []Issue
+}
+
+// PackageRule is a rule checking the files of a package together, such as
+// a constant declared in a file and used in another. The linter calls
+// CheckPackage once per package, instead of Check on each file, with all
+// the linted files of the package sharing fset.
+type PackageRule interface {
+	Rule
+	CheckPackage(fset *token.FileSet, files []*ast.File) []Issue
+}
+
+// Pass is the file visited by the node rules.
+type Pass struct {
+	Fset *token.FileSet
+	File *ast.File
+	// Pkg and Info are the types of the package of the file, nil when
+	// it does not type-check.
+	Pkg  *gotypes.Package
+	Info *gotypes.Info
+}
+
+// NodeRule is a rule checking the nodes of some types, each along with
+// its ancestors. The linter walks each file once for all its node rules,
+// instead of calling their Check method.
+type NodeRule interface {
+	Rule
+	// NodeTypes returns a node of each type the rule visits, such as
+	// (*ast.ReturnStmt)(nil).
+	NodeTypes() []ast.Node
+	// Visit checks node; stack holds its ancestors, from the file to its
+	// parent.
+	Visit(pass *Pass, node ast.Node, stack []ast.Node) []Issue
+}